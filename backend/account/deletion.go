@@ -0,0 +1,370 @@
+package account
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/counters"
+	"gin-quickstart/models"
+	"gin-quickstart/revocation"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Content policies for a deleted account's posts and comments
+const (
+	PolicyDelete   = "delete"   // Remove the content
+	PolicyReassign = "reassign" // Keep it, attributed to the shared "deleted user"
+)
+
+// deletedUsername names the placeholder account that reassigned content and
+// moderation history point at. Brackets are not valid in real usernames.
+const deletedUsername = "[deleted]"
+
+// ContentPolicy returns the configured policy (ACCOUNT_DELETION_CONTENT_POLICY, default delete)
+func ContentPolicy() string {
+	if os.Getenv("ACCOUNT_DELETION_CONTENT_POLICY") == PolicyReassign {
+		return PolicyReassign
+	}
+	return PolicyDelete
+}
+
+// DeletionGrace is how long a deletion request can be cancelled
+// (ACCOUNT_DELETION_GRACE_DAYS, default 30)
+func DeletionGrace() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"))
+	if err != nil || days < 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// deletedUser returns the placeholder account, creating it on first use. It
+// has no password or identities and is suspended, so nobody can sign in as it.
+func deletedUser(tx *gorm.DB) (*models.User, error) {
+	now := time.Now()
+	user := models.User{
+		Username:    deletedUsername,
+		Email:       "deleted@deleted.invalid",
+		FullName:    "Deleted user",
+		SuspendedAt: &now,
+	}
+	if err := tx.Where("username = ?", deletedUsername).FirstOrCreate(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// processDueDeletions erases every account whose grace period has passed
+func processDueDeletions(now time.Time) {
+	for {
+		var user models.User
+
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("deletion_scheduled_for <= ?", now).
+				Order("deletion_scheduled_for ASC").
+				First(&user).Error; err != nil {
+				return err
+			}
+
+			return erase(tx, user.ID, ContentPolicy())
+		})
+		if err == gorm.ErrRecordNotFound {
+			return
+		}
+		if err != nil {
+			log.Printf("account: failed to delete user %d: %v", user.ID, err)
+			return
+		}
+		// Cover every token issued before the revocation erase made
+		revocation.ApplyUser(user.ID, time.Now())
+		log.Printf("account: deleted user %d (%s content policy)", user.ID, ContentPolicy())
+	}
+}
+
+// erase removes a user's personal data, applies the content policy to their
+// posts and comments and anonymizes the user row
+func erase(tx *gorm.DB, userID uint, policy string) error {
+	now := time.Now()
+
+	placeholder, err := deletedUser(tx)
+	if err != nil {
+		return err
+	}
+
+	// Counters elsewhere that this account contributes to, recomputed once
+	// its rows are gone
+	affectedPosts, affectedComments, affectedUsers, err := affectedCounters(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	// End every session and drop refresh tokens
+	if err := revocation.RevokeUser(tx, userID, now); err != nil {
+		return err
+	}
+
+	if policy == PolicyReassign {
+		if err := reassignContent(tx, userID, placeholder.ID); err != nil {
+			return err
+		}
+	} else if err := deleteContent(tx, userID); err != nil {
+		return err
+	}
+
+	// Moderation history is kept, attributed to the placeholder; other
+	// references to the user are cleared
+	references := []struct {
+		model  interface{}
+		column string
+		value  interface{}
+	}{
+		{&models.Report{}, "reporter_id", placeholder.ID},
+		{&models.Report{}, "resolved_by_id", placeholder.ID},
+		{&models.ModerationLog{}, "moderator_id", placeholder.ID},
+		{&models.Notification{}, "actor_id", nil},
+		{&models.OIDCLoginState{}, "link_user_id", nil},
+	}
+	for _, r := range references {
+		if err := tx.Unscoped().Model(r.model).Where(r.column+" = ?", userID).Update(r.column, r.value).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := handOverPublications(tx, userID); err != nil {
+		return err
+	}
+
+	// Others' follows of this user's bookmark lists go with the lists
+	if err := tx.Where("list_id IN (?)", tx.Unscoped().Model(&models.BookmarkList{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&models.BookmarkListFollow{}).Error; err != nil {
+		return err
+	}
+
+	// Personal data that only means something to this user
+	owned := []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.Like{}, "user_id"},
+		{&models.CommentClap{}, "user_id"},
+		{&models.Bookmark{}, "user_id"},
+		{&models.BookmarkList{}, "user_id"},
+		{&models.BookmarkListFollow{}, "user_id"},
+		{&models.Follow{}, "follower_id"},
+		{&models.Follow{}, "following_id"},
+		{&models.FollowRequest{}, "requester_id"},
+		{&models.FollowRequest{}, "target_id"},
+		{&models.SuggestionDismissal{}, "user_id"},
+		{&models.SuggestionDismissal{}, "dismissed_id"},
+		{&models.TopicFollow{}, "user_id"},
+		{&models.SeriesFollow{}, "user_id"},
+		{&models.PublicationFollow{}, "user_id"},
+		{&models.PublicationMember{}, "user_id"},
+		{&models.PostCollaborator{}, "user_id"},
+		{&models.ReviewNote{}, "user_id"},
+		{&models.Highlight{}, "user_id"},
+		{&models.ReadingProgress{}, "user_id"},
+		{&models.DraftShareLink{}, "created_by_id"},
+		{&models.Notification{}, "user_id"},
+		{&models.Block{}, "blocker_id"},
+		{&models.Block{}, "blocked_id"},
+		{&models.Mute{}, "muter_id"},
+		{&models.Mute{}, "muted_id"},
+		{&models.DigestPost{}, "user_id"},
+		{&models.Digest{}, "user_id"},
+		{&models.RecoveryCode{}, "user_id"},
+		{&models.UserIdentity{}, "user_id"},
+		{&models.PersonalAccessToken{}, "user_id"},
+		{&models.BlacklistedToken{}, "user_id"},
+	}
+	for _, o := range owned {
+		if err := tx.Unscoped().Where(o.column+" = ?", userID).Delete(o.model).Error; err != nil {
+			return err
+		}
+	}
+
+	// Webhooks and their delivery history
+	if err := tx.Unscoped().Where("webhook_id IN (?)", tx.Unscoped().Model(&models.Webhook{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.Webhook{}).Error; err != nil {
+		return err
+	}
+
+	// Data exports and their archives
+	if err := tx.Where("export_id IN (?)", tx.Model(&models.DataExport{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&models.DataExportArchive{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.DataExport{}).Error; err != nil {
+		return err
+	}
+
+	if err := counters.RecountPosts(tx, affectedPosts); err != nil {
+		return err
+	}
+	if err := counters.RecountComments(tx, affectedComments); err != nil {
+		return err
+	}
+	if err := counters.RecountUsers(tx, append(affectedUsers, placeholder.ID)); err != nil {
+		return err
+	}
+
+	// Keep the row so its ID and revocation cutoff stay reserved, but strip
+	// everything identifying and soft-delete it
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"email":                  fmt.Sprintf("deleted-%d@deleted.invalid", userID),
+		"username":               fmt.Sprintf("[deleted-%d]", userID),
+		"password":               "",
+		"full_name":              "",
+		"bio":                    "",
+		"avatar":                 "",
+		"is_admin":               false,
+		"digest_frequency":       "off",
+		"suspension_reason":      "",
+		"two_factor_enabled":     false,
+		"totp_secret":            "",
+		"deletion_requested_at":  nil,
+		"deletion_scheduled_for": nil,
+		"deleted_at":             now,
+	}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// reassignContent attributes the user's posts and comments to the placeholder
+func reassignContent(tx *gorm.DB, userID, placeholderID uint) error {
+	if err := tx.Unscoped().Model(&models.Post{}).Where("author_id = ?", userID).Update("author_id", placeholderID).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Series{}).Where("author_id = ?", userID).Update("author_id", placeholderID).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&models.Comment{}).Where("user_id = ?", userID).Update("user_id", placeholderID).Error
+}
+
+// handOverPublications promotes the longest-standing remaining member to
+// owner of each publication the user is the only owner of
+func handOverPublications(tx *gorm.DB, userID uint) error {
+	var owned []models.PublicationMember
+	if err := tx.Where("user_id = ? AND role = ?", userID, models.PublicationRoleOwner).Find(&owned).Error; err != nil {
+		return err
+	}
+
+	for _, m := range owned {
+		var owners int64
+		if err := tx.Model(&models.PublicationMember{}).
+			Where("publication_id = ? AND role = ? AND user_id <> ?", m.PublicationID, models.PublicationRoleOwner, userID).
+			Count(&owners).Error; err != nil {
+			return err
+		}
+		if owners > 0 {
+			continue
+		}
+
+		// Editors first, then writers, oldest membership first
+		var successor models.PublicationMember
+		err := tx.Where("publication_id = ? AND user_id <> ?", m.PublicationID, userID).
+			Order(clause.OrderBy{Expression: clause.Expr{SQL: "role = ? DESC, created_at ASC", Vars: []interface{}{models.PublicationRoleEditor}}}).
+			First(&successor).Error
+		if err == gorm.ErrRecordNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&successor).Update("role", models.PublicationRoleOwner).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// affectedCounters finds the posts, comments and users whose stored
+// counters include the user's likes, claps, comments, bookmarks and follows
+func affectedCounters(tx *gorm.DB, userID uint) (posts, comments, users []uint, err error) {
+	for _, model := range []interface{}{&models.Like{}, &models.Bookmark{}, &models.Comment{}} {
+		var ids []uint
+		if err := tx.Unscoped().Model(model).Where("user_id = ?", userID).Distinct().Pluck("post_id", &ids).Error; err != nil {
+			return nil, nil, nil, err
+		}
+		posts = append(posts, ids...)
+	}
+
+	if err := tx.Model(&models.CommentClap{}).Where("user_id = ?", userID).Pluck("comment_id", &comments).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, pair := range [][2]string{{"follower_id", "following_id"}, {"following_id", "follower_id"}} {
+		var ids []uint
+		if err := tx.Unscoped().Model(&models.Follow{}).Where(pair[0]+" = ?", userID).Pluck(pair[1], &ids).Error; err != nil {
+			return nil, nil, nil, err
+		}
+		users = append(users, ids...)
+	}
+	return posts, comments, users, nil
+}
+
+// deleteContent removes the user's posts with everything attached to them,
+// and the user's comments with their replies
+func deleteContent(tx *gorm.DB, userID uint) error {
+	posts := tx.Unscoped().Model(&models.Post{}).Select("id").Where("author_id = ?", userID)
+
+	comments := tx.Unscoped().Model(&models.Comment{}).Select("id").
+		Where("user_id = ? OR post_id IN (?)", userID, posts)
+	var commentIDs []uint
+	if err := comments.Pluck("id", &commentIDs).Error; err != nil {
+		return err
+	}
+	if len(commentIDs) > 0 {
+		var replyIDs []uint
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("parent_id IN ?", commentIDs).Pluck("id", &replyIDs).Error; err != nil {
+			return err
+		}
+		commentIDs = append(commentIDs, replyIDs...)
+	}
+
+	if len(commentIDs) > 0 {
+		if err := tx.Unscoped().Where("comment_id IN ?", commentIDs).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id IN ?", commentIDs).Delete(&models.CommentClap{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id IN ?", commentIDs).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, model := range []interface{}{&models.Like{}, &models.Bookmark{}, &models.DigestPost{}, &models.Notification{},
+		&models.PostCollaborator{}, &models.DraftShareLink{}, &models.ReviewNote{}, &models.Highlight{}, &models.PostRevision{},
+		&models.ReadingProgress{}} {
+		if err := tx.Unscoped().Where("post_id IN (?)", posts).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	// Series, with their memberships and followers
+	series := tx.Unscoped().Model(&models.Series{}).Select("id").Where("author_id = ?", userID)
+	if err := tx.Where("series_id IN (?) OR post_id IN (?)", series, posts).Delete(&models.SeriesPost{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("series_id IN (?)", series).Delete(&models.SeriesFollow{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("author_id = ?", userID).Delete(&models.Series{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("author_id = ?", userID).Delete(&models.Post{}).Error
+}
@@ -0,0 +1,332 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+)
+
+// exportRetention is how long a finished archive can be downloaded
+const exportRetention = 7 * 24 * time.Hour
+
+type exportedPost struct {
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Slug        string     `json:"slug"`
+	Excerpt     string     `json:"excerpt"`
+	Content     string     `json:"content"`
+	CoverImage  string     `json:"cover_image"`
+	Tags        string     `json:"tags"`
+	Status      string     `json:"status"` // draft, scheduled, published or unlisted
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	PublishedAt *time.Time `json:"published_at"`
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
+
+type exportedComment struct {
+	ID        uint      `json:"id"`
+	PostID    uint      `json:"post_id"`
+	PostSlug  string    `json:"post_slug"`
+	ParentID  *uint     `json:"parent_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type exportedPostRef struct {
+	PostID    uint      `json:"post_id"`
+	Slug      string    `json:"slug"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportedFollow struct {
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportedTopic struct {
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportedSeries struct {
+	Title       string    `json:"title"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	Posts       []string  `json:"posts"` // post slugs in order
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type exportedListItem struct {
+	PostSlug  string    `json:"post_slug"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportedBookmarkList struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Public      bool               `json:"public"`
+	Items       []exportedListItem `json:"items"` // in list order
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+type exportedMembership struct {
+	Publication string    `json:"publication"` // slug
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type exportedHighlight struct {
+	PostSlug   string    `json:"post_slug"`
+	Quote      string    `json:"quote"`
+	Note       string    `json:"note"`
+	Visibility string    `json:"visibility"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type exportedRead struct {
+	PostSlug   string     `json:"post_slug"`
+	MaxPercent float64    `json:"max_percent"`
+	ReadAt     *time.Time `json:"read_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// archive is everything a user's export contains
+type archive struct {
+	ExportedAt   time.Time              `json:"exported_at"`
+	Profile      models.User            `json:"profile"`
+	Posts        []exportedPost         `json:"posts"`
+	Comments     []exportedComment      `json:"comments"`
+	Likes        []exportedPostRef      `json:"likes"`
+	Bookmarks    []exportedPostRef      `json:"bookmarks"`
+	Lists        []exportedBookmarkList `json:"bookmark_lists"`
+	Following    []exportedFollow       `json:"following"`
+	Followers    []exportedFollow       `json:"followers"`
+	TopicFollows []exportedTopic        `json:"topic_follows"`
+	Series       []exportedSeries       `json:"series"`
+	Publications []exportedMembership   `json:"publications"`
+	Highlights   []exportedHighlight    `json:"highlights"`
+	History      []exportedRead         `json:"reading_history"`
+}
+
+// postStatus describes a post's publishing state
+func postStatus(p models.Post) string {
+	switch {
+	case !p.Published && p.ScheduledAt != nil:
+		return "scheduled"
+	case !p.Published:
+		return "draft"
+	case p.Unlisted:
+		return "unlisted"
+	}
+	return "published"
+}
+
+// collect gathers the user's data
+func collect(userID uint) (*archive, error) {
+	a := &archive{ExportedAt: time.Now()}
+
+	if err := config.DB.First(&a.Profile, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var posts []models.Post
+	if err := config.DB.Where("author_id = ?", userID).Order("created_at ASC").Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		a.Posts = append(a.Posts, exportedPost{
+			ID: p.ID, Title: p.Title, Slug: p.Slug, Excerpt: p.Excerpt, Content: p.Content,
+			CoverImage: p.CoverImage, Tags: p.Tags, Status: postStatus(p),
+			CreatedAt: p.CreatedAt, UpdatedAt: p.UpdatedAt, PublishedAt: p.PublishedAt, ScheduledAt: p.ScheduledAt,
+		})
+	}
+
+	if err := config.DB.Table("comments").
+		Select("comments.id, comments.post_id, posts.slug AS post_slug, comments.parent_id, comments.content, comments.created_at, comments.updated_at").
+		Joins("LEFT JOIN posts ON posts.id = comments.post_id").
+		Where("comments.user_id = ? AND comments.deleted_at IS NULL", userID).
+		Order("comments.created_at ASC").
+		Scan(&a.Comments).Error; err != nil {
+		return nil, err
+	}
+
+	for table, dest := range map[string]*[]exportedPostRef{"likes": &a.Likes, "bookmarks": &a.Bookmarks} {
+		if err := config.DB.Table(table).
+			Select(table+".post_id, posts.slug, posts.title, "+table+".created_at").
+			Joins("LEFT JOIN posts ON posts.id = "+table+".post_id").
+			Where(table+".user_id = ? AND "+table+".deleted_at IS NULL", userID).
+			Order(table + ".created_at ASC").
+			Scan(dest).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var lists []models.BookmarkList
+	if err := config.DB.Where("user_id = ?", userID).Order("builtin DESC, created_at ASC").Find(&lists).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range lists {
+		exported := exportedBookmarkList{Name: l.Name, Description: l.Description, Public: l.Public, CreatedAt: l.CreatedAt}
+		if err := config.DB.Table("bookmarks").
+			Select("posts.slug AS post_slug, bookmarks.note, bookmarks.created_at").
+			Joins("LEFT JOIN posts ON posts.id = bookmarks.post_id").
+			Where("bookmarks.list_id = ? AND bookmarks.deleted_at IS NULL", l.ID).
+			Order("bookmarks.position ASC").
+			Scan(&exported.Items).Error; err != nil {
+			return nil, err
+		}
+		a.Lists = append(a.Lists, exported)
+	}
+
+	if err := config.DB.Table("follows").
+		Select("users.username, follows.created_at").
+		Joins("JOIN users ON users.id = follows.following_id").
+		Where("follows.follower_id = ? AND follows.deleted_at IS NULL", userID).
+		Order("follows.created_at ASC").
+		Scan(&a.Following).Error; err != nil {
+		return nil, err
+	}
+	if err := config.DB.Table("follows").
+		Select("users.username, follows.created_at").
+		Joins("JOIN users ON users.id = follows.follower_id").
+		Where("follows.following_id = ? AND follows.deleted_at IS NULL", userID).
+		Order("follows.created_at ASC").
+		Scan(&a.Followers).Error; err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Table("topic_follows").
+		Select("topics.name, topics.slug, topic_follows.created_at").
+		Joins("JOIN topics ON topics.id = topic_follows.topic_id").
+		Where("topic_follows.user_id = ? AND topic_follows.deleted_at IS NULL", userID).
+		Order("topic_follows.created_at ASC").
+		Scan(&a.TopicFollows).Error; err != nil {
+		return nil, err
+	}
+
+	var series []models.Series
+	if err := config.DB.Where("author_id = ?", userID).Order("created_at ASC").Find(&series).Error; err != nil {
+		return nil, err
+	}
+	for _, s := range series {
+		exported := exportedSeries{Title: s.Title, Slug: s.Slug, Description: s.Description, CreatedAt: s.CreatedAt}
+		if err := config.DB.Model(&models.Post{}).
+			Joins("JOIN series_posts ON series_posts.post_id = posts.id").
+			Where("series_posts.series_id = ?", s.ID).
+			Order("series_posts.position ASC").
+			Pluck("posts.slug", &exported.Posts).Error; err != nil {
+			return nil, err
+		}
+		a.Series = append(a.Series, exported)
+	}
+
+	if err := config.DB.Table("publication_members").
+		Select("publications.slug AS publication, publication_members.role, publication_members.created_at").
+		Joins("JOIN publications ON publications.id = publication_members.publication_id").
+		Where("publication_members.user_id = ?", userID).
+		Order("publication_members.created_at ASC").
+		Scan(&a.Publications).Error; err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Table("highlights").
+		Select("posts.slug AS post_slug, highlights.quote, highlights.note, highlights.visibility, highlights.created_at").
+		Joins("LEFT JOIN posts ON posts.id = highlights.post_id").
+		Where("highlights.user_id = ?", userID).
+		Order("highlights.created_at ASC").
+		Scan(&a.Highlights).Error; err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Table("reading_progresses").
+		Select("posts.slug AS post_slug, reading_progresses.max_percent, reading_progresses.read_at, reading_progresses.updated_at").
+		Joins("LEFT JOIN posts ON posts.id = reading_progresses.post_id").
+		Where("reading_progresses.user_id = ? AND reading_progresses.deleted_at IS NULL", userID).
+		Order("reading_progresses.updated_at DESC").
+		Scan(&a.History).Error; err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// buildExport builds the archive for an export
+func buildExport(export *models.DataExport) ([]byte, error) {
+	a, err := collect(export.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if export.Format == models.ExportFormatZIP {
+		err = writeZIP(&buf, a)
+	} else {
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(a)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeZIP lays the archive out as one JSON file per section, plus each post
+// as a Markdown file
+func writeZIP(w io.Writer, a *archive) error {
+	zw := zip.NewWriter(w)
+
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", a.Profile},
+		{"posts.json", a.Posts},
+		{"comments.json", a.Comments},
+		{"likes.json", a.Likes},
+		{"bookmarks.json", a.Bookmarks},
+		{"bookmark_lists.json", a.Lists},
+		{"following.json", a.Following},
+		{"followers.json", a.Followers},
+		{"topic_follows.json", a.TopicFollows},
+		{"series.json", a.Series},
+		{"publications.json", a.Publications},
+		{"highlights.json", a.Highlights},
+		{"reading_history.json", a.History},
+	}
+	for _, s := range sections {
+		w, err := zw.Create(s.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s.data); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range a.Posts {
+		w, err := zw.Create(fmt.Sprintf("posts/%d-%s.md", p.ID, filepath.Base(p.Slug)))
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n\n%s\n", p.Title, p.Content); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
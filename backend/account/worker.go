@@ -0,0 +1,122 @@
+package account
+
+import (
+	"log"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	pollInterval = time.Minute
+
+	// exportLease is how long an export may stay processing before another
+	// worker assumes the first one died and retries it
+	exportLease = 30 * time.Minute
+)
+
+// StartWorker builds requested exports, erases accounts whose deletion grace
+// period has passed and removes expired archives
+func StartWorker() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			now := time.Now()
+			processExports()
+			processDueDeletions(now)
+			sweepExports(now)
+			<-ticker.C
+		}
+	}()
+}
+
+// processExports builds pending exports one at a time
+func processExports() {
+	for {
+		export, err := claimExport()
+		if err == gorm.ErrRecordNotFound {
+			return
+		}
+		if err != nil {
+			log.Printf("account: failed to claim export: %v", err)
+			return
+		}
+
+		data, err := buildExport(export)
+		if err == nil {
+			err = storeExport(export.ID, data)
+		}
+		if err != nil {
+			log.Printf("account: export %d failed: %v", export.ID, err)
+			config.DB.Model(&models.DataExport{}).Where("id = ?", export.ID).Updates(map[string]interface{}{
+				"status":       models.ExportStatusFailed,
+				"error":        "Failed to build archive",
+				"completed_at": time.Now(),
+			})
+		}
+	}
+}
+
+// storeExport saves a built archive and marks its export ready. A worker
+// retrying an abandoned export replaces the archive.
+func storeExport(exportID uint, data []byte) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).
+			Create(&models.DataExportArchive{ExportID: exportID, Data: data}).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&models.DataExport{}).Where("id = ?", exportID).Updates(map[string]interface{}{
+			"status":       models.ExportStatusReady,
+			"size":         len(data),
+			"completed_at": now,
+			"expires_at":   now.Add(exportRetention),
+		}).Error
+	})
+}
+
+// claimExport locks the oldest pending (or abandoned) export and marks it processing
+func claimExport() (*models.DataExport, error) {
+	var export models.DataExport
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND started_at < ?)",
+				models.ExportStatusPending, models.ExportStatusProcessing, now.Add(-exportLease)).
+			Order("created_at ASC").
+			First(&export).Error; err != nil {
+			return err
+		}
+
+		export.Status = models.ExportStatusProcessing
+		export.StartedAt = &now
+		return tx.Model(&export).Updates(map[string]interface{}{
+			"status":     export.Status,
+			"started_at": now,
+		}).Error
+	})
+
+	return &export, err
+}
+
+// sweepExports deletes exports and their archives past the download window
+func sweepExports(now time.Time) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&models.DataExport{}).Select("id").Where("expires_at <= ?", now)
+		if err := tx.Where("export_id IN (?)", expired).Delete(&models.DataExportArchive{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("expires_at <= ?", now).Delete(&models.DataExport{}).Error
+	})
+	if err != nil {
+		log.Printf("account: failed to sweep expired exports: %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Package anchor locates quoted passages in post content so highlights stay
+// attached to the right text when a post is edited.
+package anchor
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContextLength is how many characters of surrounding text a selector keeps
+// on each side of the quote
+const ContextLength = 32
+
+// reanchorContext is the wider context Reanchor takes from the old text, to
+// tell apart repeated passages whose stored context is identical
+const reanchorContext = 4 * ContextLength
+
+// Selector identifies a passage by its exact text, the text just before and
+// after it, and where it started. Offsets count characters (code points).
+type Selector struct {
+	Quote  string
+	Prefix string
+	Suffix string
+	Start  int
+}
+
+// End returns the character offset just past the quote
+func (s Selector) End() int {
+	return s.Start + utf8.RuneCountInString(s.Quote)
+}
+
+// Resolve finds the selector's quote in content. When the quote occurs more
+// than once, the occurrence whose surroundings best match the prefix and
+// suffix wins, then the one nearest the old offset. It returns a selector
+// with the new offset and refreshed context, or false when the quote is gone.
+func Resolve(content string, sel Selector) (Selector, bool) {
+	if sel.Quote == "" {
+		return Selector{}, false
+	}
+	text := []rune(content)
+	quote := []rune(sel.Quote)
+	prefix := []rune(sel.Prefix)
+	suffix := []rune(sel.Suffix)
+
+	best, bestScore, bestDistance := -1, -1, 0
+	for _, start := range occurrences(text, quote) {
+		end := start + len(quote)
+		score := commonSuffix(text[:start], prefix) + commonPrefix(text[end:], suffix)
+		distance := start - sel.Start
+		if distance < 0 {
+			distance = -distance
+		}
+		if score > bestScore || (score == bestScore && distance < bestDistance) {
+			best, bestScore, bestDistance = start, score, distance
+		}
+	}
+	if best < 0 {
+		return Selector{}, false
+	}
+
+	return Describe(text, best, best+len(quote)), true
+}
+
+// Reanchor carries a selector made on oldContent over to newContent. The
+// passage is first found in the text it was made on, and its context rebuilt
+// from that text at a wider length, then the refreshed selector is resolved
+// in the new content. It returns false when the passage was removed.
+func Reanchor(oldContent, newContent string, sel Selector) (Selector, bool) {
+	if old, ok := Resolve(oldContent, sel); ok {
+		text := []rune(oldContent)
+		sel = describe(text, old.Start, old.End(), reanchorContext)
+	}
+	return Resolve(newContent, sel)
+}
+
+// Describe builds the selector for the characters [start, end) of text
+func Describe(text []rune, start, end int) Selector {
+	return describe(text, start, end, ContextLength)
+}
+
+// describe builds a selector keeping context characters on each side
+func describe(text []rune, start, end, context int) Selector {
+	from := start - context
+	if from < 0 {
+		from = 0
+	}
+	to := end + context
+	if to > len(text) {
+		to = len(text)
+	}
+	return Selector{
+		Quote:  string(text[start:end]),
+		Prefix: string(text[from:start]),
+		Suffix: string(text[end:to]),
+		Start:  start,
+	}
+}
+
+// occurrences returns the character offsets where quote appears in text
+func occurrences(text, quote []rune) []int {
+	var found []int
+	s, q := string(text), string(quote)
+	offset, runes := 0, 0
+	for {
+		i := strings.Index(s[offset:], q)
+		if i < 0 {
+			return found
+		}
+		runes += utf8.RuneCountInString(s[offset : offset+i])
+		found = append(found, runes)
+
+		// Step one character past the match start so overlapping matches count
+		_, size := utf8.DecodeRuneInString(s[offset+i:])
+		offset += i + size
+		runes++
+	}
+}
+
+// commonSuffix counts how many trailing characters of a and b match
+func commonSuffix(a, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return n
+}
+
+// commonPrefix counts how many leading characters of a and b match
+func commonPrefix(a, b []rune) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// Span is a highlighted range of characters [Start, End) made by a user
+type Span struct {
+	Start  int
+	End    int
+	UserID uint
+}
+
+// Hottest returns the range highlighted by the most distinct users and how
+// many users that is. Ties go to the earliest range.
+func Hottest(spans []Span) (start, end, count int) {
+	type event struct {
+		at     int
+		userID uint
+		delta  int
+	}
+	var events []event
+	for _, s := range spans {
+		if s.End <= s.Start {
+			continue
+		}
+		events = append(events, event{s.Start, s.UserID, 1}, event{s.End, s.UserID, -1})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at != events[j].at {
+			return events[i].at < events[j].at
+		}
+		return events[i].delta < events[j].delta // close ranges before opening new ones
+	})
+
+	// Sweep, counting each user once however many of their spans overlap
+	active := make(map[uint]int)
+	for i := 0; i < len(events); {
+		at := events[i].at
+		for ; i < len(events) && events[i].at == at; i++ {
+			active[events[i].userID] += events[i].delta
+			if active[events[i].userID] == 0 {
+				delete(active, events[i].userID)
+			}
+		}
+
+		if len(active) > count && i < len(events) {
+			start, end, count = at, events[i].at, len(active)
+		} else if len(active) == count && count > 0 && at == end && i < len(events) {
+			// Extend across adjacent segments with the same coverage
+			end = events[i].at
+		}
+	}
+	return start, end, count
+}
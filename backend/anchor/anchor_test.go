@@ -0,0 +1,167 @@
+package anchor
+
+import (
+	"strings"
+	"testing"
+)
+
+// selectorAt describes the occurrence of quote that starts at or after from
+func selectorAt(content, quote string, from int) Selector {
+	text := []rune(content)
+	for _, start := range occurrences(text, []rune(quote)) {
+		if start >= from {
+			return Describe(text, start, start+len([]rune(quote)))
+		}
+	}
+	panic("quote not found: " + quote)
+}
+
+func TestResolve(t *testing.T) {
+	const original = "The quick brown fox jumps over the lazy dog. The dog sleeps."
+
+	tests := []struct {
+		name      string
+		content   string
+		sel       Selector
+		wantStart int
+		wantOK    bool
+	}{
+		{
+			name:      "exact match",
+			content:   original,
+			sel:       selectorAt(original, "lazy dog", 0),
+			wantStart: 35,
+			wantOK:    true,
+		},
+		{
+			name:      "shifted offset",
+			content:   "Intro. " + original,
+			sel:       selectorAt(original, "lazy dog", 0),
+			wantStart: 42,
+			wantOK:    true,
+		},
+		{
+			name:      "ambiguous quote picks matching suffix",
+			content:   original,
+			sel:       Selector{Quote: "dog", Prefix: "The ", Suffix: " sleeps.", Start: 0},
+			wantStart: 49,
+			wantOK:    true,
+		},
+		{
+			name:      "ambiguous quote picks matching prefix",
+			content:   original,
+			sel:       Selector{Quote: "dog", Prefix: "lazy ", Start: 60},
+			wantStart: 40,
+			wantOK:    true,
+		},
+		{
+			name:      "no context picks nearest offset",
+			content:   original,
+			sel:       Selector{Quote: "dog", Start: 47},
+			wantStart: 49,
+			wantOK:    true,
+		},
+		{
+			name:    "deleted text",
+			content: "The quick brown fox jumps over the dog. The dog sleeps.",
+			sel:     selectorAt(original, "lazy dog", 0),
+			wantOK:  false,
+		},
+		{
+			name:    "empty quote",
+			content: original,
+			sel:     Selector{Start: 3},
+			wantOK:  false,
+		},
+		{
+			name:      "offsets count characters",
+			content:   "Café ☕ and croissant",
+			sel:       Selector{Quote: "croissant"},
+			wantStart: 11,
+			wantOK:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Resolve(tt.content, tt.sel)
+			if ok != tt.wantOK {
+				t.Fatalf("Resolve ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.Start != tt.wantStart || got.Quote != tt.sel.Quote {
+				t.Errorf("Resolve = %q at %d, want %q at %d", got.Quote, got.Start, tt.sel.Quote, tt.wantStart)
+			}
+			if want := Describe([]rune(tt.content), got.Start, got.End()); got != want {
+				t.Errorf("Resolve context = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestReanchor(t *testing.T) {
+	// Two copies of the quote share identical stored context on both sides
+	filler := strings.Repeat("-", 40)
+	old := "alpha " + filler + "QUOTE" + filler + " beta " + filler + "QUOTE" + filler + " gamma"
+	first := selectorAt(old, "QUOTE", 0)
+	second := selectorAt(old, "QUOTE", first.End())
+
+	// Shift the text so the first copy now sits where the second one was
+	shift := second.Start - first.Start
+	updated := strings.Repeat("x", shift) + old
+
+	if got, _ := Resolve(updated, second); got.Start == second.Start+shift {
+		t.Fatalf("stored context alone should not tell the copies apart")
+	}
+
+	got, ok := Reanchor(old, updated, second)
+	if !ok {
+		t.Fatal("Reanchor lost the passage")
+	}
+	if got.Start != second.Start+shift {
+		t.Errorf("Reanchor start = %d, want %d", got.Start, second.Start+shift)
+	}
+	if len([]rune(got.Prefix)) != ContextLength || len([]rune(got.Suffix)) != ContextLength {
+		t.Errorf("Reanchor kept %d/%d context characters, want %d", len([]rune(got.Prefix)), len([]rune(got.Suffix)), ContextLength)
+	}
+
+	if _, ok := Reanchor(old, "alpha beta gamma", second); ok {
+		t.Error("Reanchor found a deleted passage")
+	}
+}
+
+func TestHottest(t *testing.T) {
+	tests := []struct {
+		name                          string
+		spans                         []Span
+		wantStart, wantEnd, wantCount int
+	}{
+		{name: "none"},
+		{
+			name:      "overlap",
+			spans:     []Span{{0, 10, 1}, {5, 15, 2}, {8, 20, 3}},
+			wantStart: 8, wantEnd: 10, wantCount: 3,
+		},
+		{
+			name:      "same user counted once",
+			spans:     []Span{{0, 10, 1}, {2, 8, 1}, {20, 30, 2}, {25, 35, 3}},
+			wantStart: 25, wantEnd: 30, wantCount: 2,
+		},
+		{
+			name:      "tie goes to earliest",
+			spans:     []Span{{0, 5, 1}, {10, 15, 2}},
+			wantStart: 0, wantEnd: 5, wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, count := Hottest(tt.spans)
+			if start != tt.wantStart || end != tt.wantEnd || count != tt.wantCount {
+				t.Errorf("Hottest = [%d, %d) x%d, want [%d, %d) x%d", start, end, count, tt.wantStart, tt.wantEnd, tt.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+// Command reconcile-counters recomputes the like, clap, comment, bookmark,
+// follower, following and post counts stored on posts, comments and users
+// from the rows they count. Run it from the backend directory, next to .env:
+//
+//	go run ./cmd/reconcile-counters
+package main
+
+import (
+	"log"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/counters"
+)
+
+func main() {
+	config.ConnectDatabase()
+
+	start := time.Now()
+	if err := counters.Reconcile(config.DB); err != nil {
+		log.Fatal("Failed to reconcile counters:", err)
+	}
+	log.Printf("Counters reconciled in %s", time.Since(start).Round(time.Millisecond))
+}
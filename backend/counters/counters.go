@@ -0,0 +1,55 @@
+// Package counters maintains the engagement counts stored on posts, comments
+// and users. Handlers adjust them in the same transaction as the rows they
+// count; Reconcile recomputes them from scratch.
+package counters
+
+import (
+	"gin-quickstart/models"
+
+	"gorm.io/gorm"
+)
+
+// Follow moves the follower's following count and the followed user's
+// follower count by delta, after a follow is created (1) or removed (-1)
+func Follow(tx *gorm.DB, followerID, followingID uint, delta int) error {
+	if err := tx.Unscoped().Model(&models.User{}).Where("id = ?", followerID).
+		UpdateColumn("following_count", gorm.Expr("following_count + ?", delta)).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&models.User{}).Where("id = ?", followingID).
+		UpdateColumn("follower_count", gorm.Expr("follower_count + ?", delta)).Error
+}
+
+// Comments moves a post's comment count by delta
+func Comments(tx *gorm.DB, postID uint, delta int) error {
+	return tx.Unscoped().Model(&models.Post{}).Where("id = ?", postID).
+		UpdateColumn("comment_count", gorm.Expr("comment_count + ?", delta)).Error
+}
+
+// Bookmarked counts the user as having saved a post, unless the post was
+// already in another of their lists. Call it after the bookmark is created.
+func Bookmarked(tx *gorm.DB, userID, postID uint) error {
+	saves := tx.Model(&models.Bookmark{}).Select("COUNT(*)").Where("user_id = ? AND post_id = ?", userID, postID)
+	return tx.Unscoped().Model(&models.Post{}).Where("id = ? AND (?) = 1", postID, saves).
+		UpdateColumn("bookmark_count", gorm.Expr("bookmark_count + 1")).Error
+}
+
+// Unbookmarked stops counting the user as having saved any of the posts
+// that are no longer in any of their lists. Call it after the bookmarks are
+// deleted.
+func Unbookmarked(tx *gorm.DB, userID uint, postIDs []uint) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	saves := tx.Model(&models.Bookmark{}).Select("1").Where("bookmarks.user_id = ? AND bookmarks.post_id = posts.id", userID)
+	return tx.Unscoped().Model(&models.Post{}).Where("id IN ? AND NOT EXISTS (?)", postIDs, saves).
+		UpdateColumn("bookmark_count", gorm.Expr("bookmark_count - 1")).Error
+}
+
+// Posts recounts an author's published, listed posts
+func Posts(tx *gorm.DB, authorID uint) error {
+	return tx.Exec(`UPDATE users SET post_count = (
+		SELECT COUNT(*) FROM posts
+		WHERE posts.author_id = users.id AND posts.published = true AND posts.unlisted = false AND posts.deleted_at IS NULL
+	) WHERE id = ?`, authorID).Error
+}
@@ -0,0 +1,58 @@
+package counters
+
+import (
+	"gorm.io/gorm"
+)
+
+// Each statement recomputes one table's counters from the rows they count.
+// The trailing WHERE clause is filled in to limit it to some rows.
+const (
+	postCountersSQL = `UPDATE posts SET
+		like_count = (SELECT COUNT(*) FROM likes WHERE likes.post_id = posts.id AND likes.deleted_at IS NULL),
+		clap_count = (SELECT COALESCE(SUM(likes.count), 0) FROM likes WHERE likes.post_id = posts.id AND likes.deleted_at IS NULL),
+		comment_count = (SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL),
+		bookmark_count = (SELECT COUNT(DISTINCT bookmarks.user_id) FROM bookmarks WHERE bookmarks.post_id = posts.id AND bookmarks.deleted_at IS NULL)`
+
+	commentCountersSQL = `UPDATE comments SET
+		clapper_count = (SELECT COUNT(*) FROM comment_claps WHERE comment_claps.comment_id = comments.id),
+		clap_count = (SELECT COALESCE(SUM(comment_claps.count), 0) FROM comment_claps WHERE comment_claps.comment_id = comments.id)`
+
+	userCountersSQL = `UPDATE users SET
+		follower_count = (SELECT COUNT(*) FROM follows WHERE follows.following_id = users.id AND follows.deleted_at IS NULL),
+		following_count = (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id AND follows.deleted_at IS NULL),
+		post_count = (SELECT COUNT(*) FROM posts WHERE posts.author_id = users.id AND posts.published = true AND posts.unlisted = false AND posts.deleted_at IS NULL)`
+)
+
+// RecountPosts recomputes the counters of the given posts
+func RecountPosts(tx *gorm.DB, ids []uint) error {
+	return recount(tx, postCountersSQL, ids)
+}
+
+// RecountComments recomputes the counters of the given comments
+func RecountComments(tx *gorm.DB, ids []uint) error {
+	return recount(tx, commentCountersSQL, ids)
+}
+
+// RecountUsers recomputes the counters of the given users
+func RecountUsers(tx *gorm.DB, ids []uint) error {
+	return recount(tx, userCountersSQL, ids)
+}
+
+// recount runs a counter statement for the given rows
+func recount(tx *gorm.DB, statement string, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Exec(statement+" WHERE id IN ?", ids).Error
+}
+
+// Reconcile recomputes every counter from the rows it counts, correcting any
+// drift. Each table is updated in one statement.
+func Reconcile(db *gorm.DB) error {
+	for _, statement := range []string{postCountersSQL, commentCountersSQL, userCountersSQL} {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package digest
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/mailer"
+	"gin-quickstart/models"
+
+	"gorm.io/gorm"
+)
+
+// Digest frequencies stored on models.User
+const (
+	FrequencyOff    = "off"
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+const (
+	checkInterval = time.Hour
+	maxPosts      = 20
+)
+
+// IsValidFrequency reports whether f is a supported digest frequency
+func IsValidFrequency(f string) bool {
+	return f == FrequencyOff || f == FrequencyDaily || f == FrequencyWeekly
+}
+
+// period returns how often a digest with the given frequency is sent
+func period(frequency string) time.Duration {
+	if frequency == FrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// StartScheduler checks for due digests every hour in the background
+func StartScheduler() {
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for {
+			Run(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+// Run sends every digest that is due at the given time
+func Run(now time.Time) {
+	var users []models.User
+	if err := config.DB.Where("digest_frequency IN ?", []string{FrequencyDaily, FrequencyWeekly}).
+		Find(&users).Error; err != nil {
+		log.Printf("digest: failed to load subscribers: %v", err)
+		return
+	}
+
+	for _, user := range users {
+		since := now.Add(-period(user.DigestFrequency))
+		if user.LastDigestAt != nil {
+			if user.LastDigestAt.After(since) {
+				continue // Not due yet
+			}
+			since = *user.LastDigestAt
+		}
+
+		if !claim(user, now) {
+			continue // Another replica picked this user up
+		}
+
+		if err := send(user, since, now); err != nil {
+			log.Printf("digest: failed to send to user %d: %v", user.ID, err)
+			release(user, now)
+		}
+	}
+}
+
+// claim moves last_digest_at forward only if no one else has, so several
+// replicas running the job never send the same digest twice.
+func claim(user models.User, now time.Time) bool {
+	query := config.DB.Model(&models.User{}).Where("id = ?", user.ID)
+	if user.LastDigestAt == nil {
+		query = query.Where("last_digest_at IS NULL")
+	} else {
+		query = query.Where("last_digest_at = ?", *user.LastDigestAt)
+	}
+
+	result := query.Update("last_digest_at", now)
+	return result.Error == nil && result.RowsAffected == 1
+}
+
+// release undoes a claim after a failed send, so the next run retries the
+// same period
+func release(user models.User, now time.Time) {
+	if err := config.DB.Model(&models.User{}).
+		Where("id = ? AND last_digest_at = ?", user.ID, now).
+		Update("last_digest_at", user.LastDigestAt).Error; err != nil {
+		log.Printf("digest: failed to release user %d: %v", user.ID, err)
+	}
+}
+
+// send gathers new posts for the user and mails them, recording what was sent
+func send(user models.User, since, now time.Time) error {
+	posts, err := collectPosts(user.ID, since)
+	if err != nil {
+		return err
+	}
+	if len(posts) == 0 {
+		return nil
+	}
+
+	msg, err := render(user, posts)
+	if err != nil {
+		return err
+	}
+
+	// Send with no transaction open. A failed send records nothing, so the
+	// caller releases the claim and the posts go out next time.
+	if err := mailer.Default.Send(msg); err != nil {
+		return err
+	}
+
+	// The email is out, so a failure to record it is only logged: returning
+	// it would release the claim and send the same digest again
+	if err := record(user, posts, since, now); err != nil {
+		log.Printf("digest: sent to user %d but failed to record it: %v", user.ID, err)
+	}
+	return nil
+}
+
+// record stores a sent digest and marks its posts as sent to the user
+func record(user models.User, posts []models.Post, since, now time.Time) error {
+	digest := models.Digest{
+		UserID:    user.ID,
+		Frequency: user.DigestFrequency,
+		PostCount: len(posts),
+		Since:     since,
+		SentAt:    now,
+	}
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&digest).Error; err != nil {
+			return err
+		}
+		sent := make([]models.DigestPost, len(posts))
+		for i, p := range posts {
+			sent[i] = models.DigestPost{DigestID: digest.ID, UserID: user.ID, PostID: p.ID}
+		}
+		return tx.Create(&sent).Error
+	})
+}
+
+// collectPosts returns up to maxPosts posts published since the given time by
+// followed writers or tagged with followed topics, skipping anything already
+// sent to the user. Private accounts only reach followers they approved. Tags
+// match topics as models.Topic.Matches does.
+func collectPosts(userID uint, since time.Time) ([]models.Post, error) {
+	var posts []models.Post
+	err := config.DB.Preload("Author").
+		Where("posts.published = @true AND posts.unlisted = @false AND posts.published_at > @since AND posts.author_id <> @user", map[string]interface{}{
+			"true": true, "false": false, "since": since, "user": userID,
+		}).
+		Where("NOT EXISTS (SELECT 1 FROM digest_posts WHERE digest_posts.user_id = ? AND digest_posts.post_id = posts.id)", userID).
+		Where(`posts.author_id IN (SELECT following_id FROM follows WHERE follower_id = @user AND deleted_at IS NULL)
+			OR (posts.author_id NOT IN (SELECT id FROM users WHERE private = @true)
+				AND EXISTS (
+					SELECT 1 FROM topic_follows
+					JOIN topics ON topics.id = topic_follows.topic_id
+					WHERE topic_follows.user_id = @user AND topic_follows.deleted_at IS NULL
+						AND EXISTS (
+							SELECT 1 FROM unnest(string_to_array(posts.tags, ',')) AS tag
+							WHERE lower(trim(tag)) IN (topics.slug, lower(topics.name))
+						)
+				))`, map[string]interface{}{"true": true, "user": userID}).
+		Order("posts.published_at DESC").
+		Limit(maxPosts).
+		Find(&posts).Error
+	return posts, err
+}
+
+// render builds the HTML and plain-text digest email
+func render(user models.User, posts []models.Post) (mailer.Message, error) {
+	if user.Email == "" {
+		return mailer.Message{}, errors.New("user has no email address")
+	}
+
+	token, err := UnsubscribeToken(user.ID)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+
+	apiURL := envOrDefault("API_URL", "http://localhost:8080")
+	appURL := envOrDefault("APP_URL", "http://localhost:5173")
+	unsubscribeURL := apiURL + "/api/digest/unsubscribe?token=" + url.QueryEscape(token)
+
+	name := user.FullName
+	if name == "" {
+		name = user.Username
+	}
+
+	periodLabel := "today"
+	if user.DigestFrequency == FrequencyWeekly {
+		periodLabel = "this week"
+	}
+
+	data := templateData{
+		Name:           name,
+		Period:         periodLabel,
+		UnsubscribeURL: unsubscribeURL,
+	}
+	for _, p := range posts {
+		author := p.Author.FullName
+		if author == "" {
+			author = p.Author.Username
+		}
+		data.Posts = append(data.Posts, templatePost{
+			Title:    p.Title,
+			Excerpt:  p.Excerpt,
+			Author:   author,
+			URL:      appURL + "/posts/" + p.Slug,
+			ReadTime: p.ReadTime,
+		})
+	}
+
+	var html, text bytes.Buffer
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return mailer.Message{}, err
+	}
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return mailer.Message{}, err
+	}
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Your " + user.DigestFrequency + " digest",
+		HTML:    html.String(),
+		Text:    text.String(),
+		Headers: map[string]string{
+			// RFC 8058 one-click unsubscribe
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
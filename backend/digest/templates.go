@@ -0,0 +1,91 @@
+package digest
+
+import (
+	htmltemplate "html/template"
+	"io"
+	texttemplate "text/template"
+)
+
+// templateData is passed to both digest templates
+type templateData struct {
+	Name           string
+	Period         string // "today" or "this week"
+	Posts          []templatePost
+	UnsubscribeURL string
+}
+
+type templatePost struct {
+	Title    string
+	Excerpt  string
+	Author   string
+	URL      string
+	ReadTime int
+}
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Georgia, serif; max-width: 600px; margin: 0 auto; color: #242424;">
+  <h1 style="font-size: 24px;">Your stories {{.Period}}</h1>
+  <p>Hi {{.Name}}, here is what the writers and topics you follow published {{.Period}}.</p>
+  {{range .Posts}}
+  <div style="border-bottom: 1px solid #e6e6e6; padding: 16px 0;">
+    <p style="margin: 0; color: #6b6b6b; font-size: 14px;">{{.Author}}</p>
+    <h2 style="margin: 4px 0; font-size: 20px;"><a href="{{.URL}}" style="color: #242424; text-decoration: none;">{{.Title}}</a></h2>
+    {{if .Excerpt}}<p style="margin: 4px 0; color: #6b6b6b;">{{.Excerpt}}</p>{{end}}
+    <p style="margin: 0; color: #6b6b6b; font-size: 13px;">{{.ReadTime}} min read</p>
+  </div>
+  {{end}}
+  <p style="font-size: 12px; color: #6b6b6b; margin-top: 24px;">
+    You are receiving this because you subscribed to email digests.
+    <a href="{{.UnsubscribeURL}}">Unsubscribe</a>.
+  </p>
+</body>
+</html>
+`))
+
+var textTemplate = texttemplate.Must(texttemplate.New("digest.txt").Parse(`Your stories {{.Period}}
+
+Hi {{.Name}}, here is what the writers and topics you follow published {{.Period}}.
+{{range .Posts}}
+{{.Title}}
+by {{.Author}} - {{.ReadTime}} min read
+{{if .Excerpt}}{{.Excerpt}}
+{{end}}{{.URL}}
+{{end}}
+--
+You are receiving this because you subscribed to email digests.
+Unsubscribe: {{.UnsubscribeURL}}
+`))
+
+// unsubscribeData is passed to the unsubscribe page
+type unsubscribeData struct {
+	Token   string
+	Done    bool
+	Invalid bool
+}
+
+var unsubscribeTemplate = htmltemplate.Must(htmltemplate.New("unsubscribe.html").Parse(`<!DOCTYPE html>
+<html>
+<head><meta name="robots" content="noindex"><title>Email digest</title></head>
+<body style="font-family: Georgia, serif; max-width: 600px; margin: 48px auto; color: #242424;">
+  {{if .Invalid}}
+  <h1 style="font-size: 24px;">This unsubscribe link is not valid</h1>
+  <p>You can turn the digest off from your account settings instead.</p>
+  {{else if .Done}}
+  <h1 style="font-size: 24px;">You have been unsubscribed</h1>
+  <p>You will no longer receive email digests. You can turn them back on from your account settings.</p>
+  {{else}}
+  <h1 style="font-size: 24px;">Unsubscribe from email digests?</h1>
+  <form method="post" action="?token={{.Token}}">
+    <button type="submit" style="font-size: 16px; padding: 8px 16px;">Unsubscribe</button>
+  </form>
+  {{end}}
+</body>
+</html>
+`))
+
+// UnsubscribePage renders the page behind the email's unsubscribe link: a
+// confirmation form for a valid token, the result once done, or an error
+func UnsubscribePage(w io.Writer, token string, done, invalid bool) error {
+	return unsubscribeTemplate.Execute(w, unsubscribeData{Token: token, Done: done, Invalid: invalid})
+}
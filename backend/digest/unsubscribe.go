@@ -0,0 +1,64 @@
+package digest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// signingKey reads DIGEST_SECRET
+func signingKey() ([]byte, error) {
+	secret := os.Getenv("DIGEST_SECRET")
+	if secret == "" {
+		return nil, errors.New("DIGEST_SECRET not set in environment")
+	}
+	return []byte(secret), nil
+}
+
+// CheckSigningKey reports whether DIGEST_SECRET is configured. Run it at
+// startup: without it, unsubscribe links can be neither made nor checked.
+func CheckSigningKey() error {
+	_, err := signingKey()
+	return err
+}
+
+func signUserID(key []byte, userID string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("digest-unsubscribe:" + userID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// UnsubscribeToken creates a signed token that turns off the user's digest
+func UnsubscribeToken(userID uint) (string, error) {
+	key, err := signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	id := strconv.FormatUint(uint64(userID), 10)
+	return id + "." + signUserID(key, id), nil
+}
+
+// ParseUnsubscribeToken verifies a token and returns the user ID it was issued for
+func ParseUnsubscribeToken(token string) (uint, error) {
+	key, err := signingKey()
+	if err != nil {
+		return 0, err
+	}
+
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signUserID(key, id))) {
+		return 0, errors.New("invalid unsubscribe token")
+	}
+
+	userID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, errors.New("invalid unsubscribe token")
+	}
+
+	return uint(userID), nil
+}
@@ -0,0 +1,122 @@
+package feed
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Reasons a post appears in the for-you feed
+const (
+	ReasonFollowedAuthor = "followed_author"
+	ReasonFollowedTopic  = "followed_topic"
+	ReasonTrending       = "trending"
+	ReasonForYou         = "recommended" // Ranked in on the reader's history alone
+)
+
+// Candidate is a post eligible for the feed with the features used to rank it
+type Candidate struct {
+	PostID         uint
+	AuthorID       uint
+	Tags           []string
+	PublishedAt    time.Time
+	FollowedAuthor bool
+	TopicMatches   int   // Followed topics the post is tagged with
+	Engagement     int64 // Recent likes plus comments from everyone
+}
+
+// Profile is what the reader's history says about their interests
+type Profile struct {
+	Authors map[uint]float64   // Weighted interactions with each author's posts
+	Tags    map[string]float64 // Weighted interactions with posts carrying each tag
+}
+
+// Ranked is a candidate with its final score and the main reason it was picked
+type Ranked struct {
+	Candidate
+	Score  float64
+	Reason string
+}
+
+// Score rates a candidate for the reader before diversity is applied
+func Score(c Candidate, profile Profile, w Weights, now time.Time) float64 {
+	score := 0.0
+	if c.FollowedAuthor {
+		score += w.FollowedAuthor
+	}
+	score += w.FollowedTopic * float64(c.TopicMatches)
+	score += w.AuthorAffinity * math.Log1p(profile.Authors[c.AuthorID])
+
+	tagAffinity := 0.0
+	for _, tag := range c.Tags {
+		tagAffinity += profile.Tags[tag]
+	}
+	score += w.TagAffinity * math.Log1p(tagAffinity)
+	score += w.Trending * math.Log1p(float64(c.Engagement))
+
+	// Exponential decay with age
+	if w.HalfLife > 0 {
+		age := now.Sub(c.PublishedAt)
+		if age < 0 {
+			age = 0
+		}
+		score *= math.Pow(0.5, float64(age)/float64(w.HalfLife))
+	}
+	return score
+}
+
+// reason names the strongest source of a candidate
+func reason(c Candidate) string {
+	switch {
+	case c.FollowedAuthor:
+		return ReasonFollowedAuthor
+	case c.TopicMatches > 0:
+		return ReasonFollowedTopic
+	case c.Engagement > 0:
+		return ReasonTrending
+	}
+	return ReasonForYou
+}
+
+// Rank orders candidates by score, then spreads authors out: each further
+// post from an author already placed has its score multiplied by
+// AuthorPenalty. Ties break on post ID, so equal input gives equal output.
+func Rank(candidates []Candidate, profile Profile, w Weights, now time.Time) []Ranked {
+	pool := make([]Ranked, len(candidates))
+	for i, c := range candidates {
+		pool[i] = Ranked{Candidate: c, Score: Score(c, profile, w, now), Reason: reason(c)}
+	}
+	sort.Slice(pool, func(i, j int) bool {
+		if pool[i].Score != pool[j].Score {
+			return pool[i].Score > pool[j].Score
+		}
+		return pool[i].PostID > pool[j].PostID
+	})
+
+	placed := make(map[uint]int)
+	result := make([]Ranked, 0, len(pool))
+	used := make([]bool, len(pool))
+
+	for len(result) < len(pool) {
+		best := -1
+		bestScore := 0.0
+		for i := range pool {
+			if used[i] {
+				continue
+			}
+			adjusted := pool[i].Score * math.Pow(w.AuthorPenalty, float64(placed[pool[i].AuthorID]))
+			// Pool is sorted, so the first of equal adjusted scores wins
+			if best == -1 || adjusted > bestScore {
+				best, bestScore = i, adjusted
+			}
+		}
+
+		used[best] = true
+		placed[pool[best].AuthorID]++
+		picked := pool[best]
+		picked.Score = bestScore
+		result = append(result, picked)
+	}
+
+	return result
+}
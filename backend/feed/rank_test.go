@@ -0,0 +1,234 @@
+package feed
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+
+// onlyWeights returns weights with every factor off except the ones set
+func onlyWeights(set func(*Weights)) Weights {
+	w := Weights{AuthorPenalty: 1}
+	set(&w)
+	return w
+}
+
+func emptyProfile() Profile {
+	return Profile{Authors: map[uint]float64{}, Tags: map[string]float64{}}
+}
+
+func TestScore(t *testing.T) {
+	profile := Profile{
+		Authors: map[uint]float64{7: math.E - 1},
+		Tags:    map[string]float64{"go": 1, "web": math.E - 2},
+	}
+
+	tests := []struct {
+		name      string
+		candidate Candidate
+		weights   Weights
+		want      float64
+	}{
+		{
+			name:      "followed author",
+			candidate: Candidate{AuthorID: 1, FollowedAuthor: true, PublishedAt: now},
+			weights:   onlyWeights(func(w *Weights) { w.FollowedAuthor = 3 }),
+			want:      3,
+		},
+		{
+			name:      "followed topics add up",
+			candidate: Candidate{AuthorID: 1, TopicMatches: 2, PublishedAt: now},
+			weights:   onlyWeights(func(w *Weights) { w.FollowedTopic = 1.5 }),
+			want:      3,
+		},
+		{
+			name:      "author affinity is logarithmic",
+			candidate: Candidate{AuthorID: 7, PublishedAt: now},
+			weights:   onlyWeights(func(w *Weights) { w.AuthorAffinity = 2 }),
+			want:      2, // 2 * log1p(e-1)
+		},
+		{
+			name:      "tag affinity sums the post's tags",
+			candidate: Candidate{AuthorID: 1, Tags: []string{"go", "web", "unknown"}, PublishedAt: now},
+			weights:   onlyWeights(func(w *Weights) { w.TagAffinity = 1 }),
+			want:      1, // log1p(1 + e-2)
+		},
+		{
+			name:      "unknown author has no affinity",
+			candidate: Candidate{AuthorID: 8, PublishedAt: now},
+			weights:   onlyWeights(func(w *Weights) { w.AuthorAffinity = 2 }),
+			want:      0,
+		},
+		{
+			name:      "trending",
+			candidate: Candidate{AuthorID: 1, Engagement: 9, PublishedAt: now},
+			weights:   onlyWeights(func(w *Weights) { w.Trending = 1 }),
+			want:      math.Log(10),
+		},
+		{
+			name:      "score halves at the half-life",
+			candidate: Candidate{AuthorID: 1, FollowedAuthor: true, PublishedAt: now.Add(-48 * time.Hour)},
+			weights:   onlyWeights(func(w *Weights) { w.FollowedAuthor = 4; w.HalfLife = 48 * time.Hour }),
+			want:      2,
+		},
+		{
+			name:      "quarter after two half-lives",
+			candidate: Candidate{AuthorID: 1, FollowedAuthor: true, PublishedAt: now.Add(-96 * time.Hour)},
+			weights:   onlyWeights(func(w *Weights) { w.FollowedAuthor = 4; w.HalfLife = 48 * time.Hour }),
+			want:      1,
+		},
+		{
+			name:      "future posts don't gain score",
+			candidate: Candidate{AuthorID: 1, FollowedAuthor: true, PublishedAt: now.Add(time.Hour)},
+			weights:   onlyWeights(func(w *Weights) { w.FollowedAuthor = 4; w.HalfLife = 48 * time.Hour }),
+			want:      4,
+		},
+		{
+			name:      "no decay without a half-life",
+			candidate: Candidate{AuthorID: 1, FollowedAuthor: true, PublishedAt: now.Add(-1000 * time.Hour)},
+			weights:   onlyWeights(func(w *Weights) { w.FollowedAuthor = 4 }),
+			want:      4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Score(tt.candidate, profile, tt.weights, now)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	followed := onlyWeights(func(w *Weights) { w.FollowedAuthor = 1; w.Trending = 1 })
+
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		weights    Weights
+		want       []uint // Post IDs in ranked order
+	}{
+		{
+			name: "higher scores first",
+			candidates: []Candidate{
+				{PostID: 1, AuthorID: 1, Engagement: 1, PublishedAt: now},
+				{PostID: 2, AuthorID: 2, Engagement: 10, PublishedAt: now},
+				{PostID: 3, AuthorID: 3, Engagement: 5, PublishedAt: now},
+			},
+			weights: followed,
+			want:    []uint{2, 3, 1},
+		},
+		{
+			name: "ties break on newest post ID",
+			candidates: []Candidate{
+				{PostID: 4, AuthorID: 1, FollowedAuthor: true, PublishedAt: now},
+				{PostID: 9, AuthorID: 2, FollowedAuthor: true, PublishedAt: now},
+				{PostID: 6, AuthorID: 3, FollowedAuthor: true, PublishedAt: now},
+			},
+			weights: followed,
+			want:    []uint{9, 6, 4},
+		},
+		{
+			name: "author penalty spreads authors out",
+			candidates: []Candidate{
+				{PostID: 1, AuthorID: 1, Engagement: 99, PublishedAt: now},
+				{PostID: 2, AuthorID: 1, Engagement: 98, PublishedAt: now},
+				{PostID: 3, AuthorID: 2, Engagement: 20, PublishedAt: now},
+			},
+			weights: onlyWeights(func(w *Weights) { w.Trending = 1; w.AuthorPenalty = 0.5 }),
+			want:    []uint{1, 3, 2}, // log(99)/2 < log(21)
+		},
+		{
+			name: "no penalty keeps score order",
+			candidates: []Candidate{
+				{PostID: 1, AuthorID: 1, Engagement: 99, PublishedAt: now},
+				{PostID: 2, AuthorID: 1, Engagement: 98, PublishedAt: now},
+				{PostID: 3, AuthorID: 2, Engagement: 20, PublishedAt: now},
+			},
+			weights: onlyWeights(func(w *Weights) { w.Trending = 1; w.AuthorPenalty = 1 }),
+			want:    []uint{1, 2, 3},
+		},
+		{
+			name:       "empty",
+			candidates: nil,
+			weights:    DefaultWeights,
+			want:       []uint{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := Rank(tt.candidates, emptyProfile(), tt.weights, now)
+			got := make([]uint, len(ranked))
+			for i, r := range ranked {
+				got[i] = r.PostID
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Rank = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Rank = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRankAppliesPenaltyToScores(t *testing.T) {
+	candidates := []Candidate{
+		{PostID: 1, AuthorID: 1, FollowedAuthor: true, PublishedAt: now},
+		{PostID: 2, AuthorID: 1, FollowedAuthor: true, PublishedAt: now},
+		{PostID: 3, AuthorID: 1, FollowedAuthor: true, PublishedAt: now},
+	}
+	w := onlyWeights(func(w *Weights) { w.FollowedAuthor = 8; w.AuthorPenalty = 0.5 })
+
+	ranked := Rank(candidates, emptyProfile(), w, now)
+	for i, want := range []float64{8, 4, 2} {
+		if ranked[i].Score != want {
+			t.Errorf("score %d = %v, want %v", i, ranked[i].Score, want)
+		}
+	}
+}
+
+func TestRankIsDeterministic(t *testing.T) {
+	candidates := []Candidate{
+		{PostID: 5, AuthorID: 1, Tags: []string{"go"}, Engagement: 3, PublishedAt: now.Add(-time.Hour)},
+		{PostID: 3, AuthorID: 2, FollowedAuthor: true, PublishedAt: now.Add(-2 * time.Hour)},
+		{PostID: 8, AuthorID: 1, TopicMatches: 1, PublishedAt: now},
+		{PostID: 1, AuthorID: 3, Engagement: 3, PublishedAt: now.Add(-time.Hour)},
+	}
+	profile := Profile{Authors: map[uint]float64{1: 2}, Tags: map[string]float64{"go": 1}}
+
+	first := Rank(candidates, profile, DefaultWeights, now)
+	// Same input in another order
+	reversed := []Candidate{candidates[3], candidates[2], candidates[1], candidates[0]}
+	second := Rank(reversed, profile, DefaultWeights, now)
+
+	for i := range first {
+		if first[i].PostID != second[i].PostID || first[i].Score != second[i].Score {
+			t.Fatalf("rankings differ at %d: %+v vs %+v", i, first[i], second[i])
+		}
+	}
+}
+
+func TestReason(t *testing.T) {
+	tests := []struct {
+		candidate Candidate
+		want      string
+	}{
+		{Candidate{FollowedAuthor: true, TopicMatches: 1, Engagement: 5}, ReasonFollowedAuthor},
+		{Candidate{TopicMatches: 1, Engagement: 5}, ReasonFollowedTopic},
+		{Candidate{Engagement: 5}, ReasonTrending},
+		{Candidate{}, ReasonForYou},
+	}
+	for _, tt := range tests {
+		if got := reason(tt.candidate); got != tt.want {
+			t.Errorf("reason(%+v) = %q, want %q", tt.candidate, got, tt.want)
+		}
+	}
+}
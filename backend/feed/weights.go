@@ -0,0 +1,78 @@
+package feed
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Weights tunes the for-you ranking. Every field can be overridden with the
+// FOR_YOU_* environment variable named in its comment.
+type Weights struct {
+	FollowedAuthor float64 // FOR_YOU_WEIGHT_FOLLOWED_AUTHOR: post is by someone the reader follows
+	FollowedTopic  float64 // FOR_YOU_WEIGHT_FOLLOWED_TOPIC: per followed topic the post is tagged with
+	AuthorAffinity float64 // FOR_YOU_WEIGHT_AUTHOR_AFFINITY: reader's history with the author
+	TagAffinity    float64 // FOR_YOU_WEIGHT_TAG_AFFINITY: reader's history with the post's tags
+	Trending       float64 // FOR_YOU_WEIGHT_TRENDING: recent likes and comments from everyone
+
+	// How much each kind of interaction in the reader's history counts
+	Like     float64 // FOR_YOU_WEIGHT_LIKE
+	Bookmark float64 // FOR_YOU_WEIGHT_BOOKMARK
+	Comment  float64 // FOR_YOU_WEIGHT_COMMENT
+	Read     float64 // FOR_YOU_WEIGHT_READ: reading a post past the read threshold
+
+	HalfLife      time.Duration // FOR_YOU_HALF_LIFE_HOURS: age at which a post's score halves
+	AuthorPenalty float64       // FOR_YOU_AUTHOR_PENALTY: multiplier per post already shown by the same author
+	Window        time.Duration // FOR_YOU_WINDOW_DAYS: oldest posts considered
+}
+
+// DefaultWeights are used for anything not set in the environment
+var DefaultWeights = Weights{
+	FollowedAuthor: 3,
+	FollowedTopic:  1.5,
+	AuthorAffinity: 1,
+	TagAffinity:    0.75,
+	Trending:       1,
+	Like:           1,
+	Bookmark:       2,
+	Comment:        3,
+	Read:           0.5,
+	HalfLife:       48 * time.Hour,
+	AuthorPenalty:  0.5,
+	Window:         30 * 24 * time.Hour,
+}
+
+// LoadWeights reads the ranking weights from the environment
+func LoadWeights() Weights {
+	w := DefaultWeights
+	envFloat("FOR_YOU_WEIGHT_FOLLOWED_AUTHOR", &w.FollowedAuthor)
+	envFloat("FOR_YOU_WEIGHT_FOLLOWED_TOPIC", &w.FollowedTopic)
+	envFloat("FOR_YOU_WEIGHT_AUTHOR_AFFINITY", &w.AuthorAffinity)
+	envFloat("FOR_YOU_WEIGHT_TAG_AFFINITY", &w.TagAffinity)
+	envFloat("FOR_YOU_WEIGHT_TRENDING", &w.Trending)
+	envFloat("FOR_YOU_WEIGHT_LIKE", &w.Like)
+	envFloat("FOR_YOU_WEIGHT_BOOKMARK", &w.Bookmark)
+	envFloat("FOR_YOU_WEIGHT_COMMENT", &w.Comment)
+	envFloat("FOR_YOU_WEIGHT_READ", &w.Read)
+	envFloat("FOR_YOU_AUTHOR_PENALTY", &w.AuthorPenalty)
+
+	var hours, days float64
+	if envFloat("FOR_YOU_HALF_LIFE_HOURS", &hours) && hours > 0 {
+		w.HalfLife = time.Duration(hours * float64(time.Hour))
+	}
+	if envFloat("FOR_YOU_WINDOW_DAYS", &days) && days > 0 {
+		w.Window = time.Duration(days * 24 * float64(time.Hour))
+	}
+	return w
+}
+
+// envFloat overwrites dest with a numeric environment variable, reporting
+// whether it was set and valid
+func envFloat(name string, dest *float64) bool {
+	value, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil || value < 0 {
+		return false
+	}
+	*dest = value
+	return true
+}
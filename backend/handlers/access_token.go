@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+	"gin-quickstart/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTokensPerUser caps how many personal access tokens one account may hold
+const maxTokensPerUser = 50
+
+// CreatePersonalAccessToken issues a new personal access token. The token is
+// only returned in this response; afterwards only its hash is kept.
+func CreatePersonalAccessToken(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name          string   `json:"name" binding:"required,max=100"`
+		Scopes        []string `json:"scopes" binding:"required"`
+		ExpiresInDays int      `json:"expires_in_days"` // 0 means no expiry
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+	if len(input.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one scope is required"})
+		return
+	}
+	if input.ExpiresInDays < 0 || input.ExpiresInDays > 366 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days must be between 0 and 366"})
+		return
+	}
+
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, s := range input.Scopes {
+		if !models.IsValidScope(s) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + s})
+			return
+		}
+		if s == models.ScopeAdmin && !user.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can create tokens with the admin scope"})
+			return
+		}
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+
+	var count int64
+	config.DB.Model(&models.PersonalAccessToken{}).Where("user_id = ?", user.ID).Count(&count)
+	if count >= maxTokensPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token limit reached; revoke an unused token first"})
+		return
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	raw := models.PersonalAccessTokenPrefix + secret
+
+	token := models.PersonalAccessToken{
+		UserID:    user.ID,
+		Name:      input.Name,
+		TokenHash: utils.HashToken(raw),
+		Hint:      raw[len(raw)-4:],
+		Scopes:    strings.Join(scopes, ","),
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := config.DB.Create(&token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"token": token, "secret": raw})
+}
+
+// GetPersonalAccessTokens lists the authenticated user's personal access tokens
+func GetPersonalAccessTokens(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var tokens []models.PersonalAccessToken
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens, "scopes": models.TokenScopes})
+}
+
+// RevokePersonalAccessToken deletes one of the user's personal access tokens
+func RevokePersonalAccessToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.PersonalAccessToken{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
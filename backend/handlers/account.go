@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"gin-quickstart/account"
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportCooldown is how long a finished export is reused before a new one is built
+const exportCooldown = 24 * time.Hour
+
+// RequestDataExport returns the user's latest data export, queueing a new one
+// when none is in progress or recent. Poll until status is "ready", then
+// fetch the archive from download_url.
+func RequestDataExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	format := c.DefaultQuery("format", models.ExportFormatZIP)
+	if format != models.ExportFormatZIP && format != models.ExportFormatJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format must be zip or json"})
+		return
+	}
+
+	var export models.DataExport
+	err := config.DB.Where("user_id = ? AND format = ? AND (status IN ? OR (status = ? AND completed_at > ?))",
+		userID, format,
+		[]string{models.ExportStatusPending, models.ExportStatusProcessing},
+		models.ExportStatusReady, time.Now().Add(-exportCooldown)).
+		Order("created_at DESC").
+		First(&export).Error
+	if err != nil {
+		export = models.DataExport{
+			UserID: userID.(uint),
+			Format: format,
+			Status: models.ExportStatusPending,
+		}
+		if err := config.DB.Create(&export).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue export"})
+			return
+		}
+	}
+
+	status := http.StatusAccepted
+	response := gin.H{"export": export}
+	if export.Status == models.ExportStatusReady {
+		status = http.StatusOK
+		response["download_url"] = fmt.Sprintf("/api/user/export/%d/download", export.ID)
+	}
+
+	c.JSON(status, response)
+}
+
+// DownloadDataExport sends a finished export archive
+func DownloadDataExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var export models.DataExport
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&export).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+
+	var archive models.DataExportArchive
+	if export.Status != models.ExportStatusReady || config.DB.First(&archive, export.ID).Error != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Export is not ready"})
+		return
+	}
+
+	contentType := "application/zip"
+	if export.Format == models.ExportFormatJSON {
+		contentType = "application/json"
+	}
+	filename := fmt.Sprintf("export-%s.%s", export.CreatedAt.Format("2006-01-02"), export.Format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, contentType, archive.Data)
+}
+
+// DeleteAccount schedules the user's account for deletion after the grace
+// period. Signing in and calling CancelAccountDeletion stops it.
+func DeleteAccount(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Accounts created through social login have no password to confirm
+	if user.Password != "" && user.CheckPassword(input.Password) != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+	if user.TwoFactorEnabled && !verifySecondFactor(user, input.Code, input.RecoveryCode) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+
+	if user.DeletionScheduledFor != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account deletion is already scheduled", "deletion_scheduled_for": user.DeletionScheduledFor})
+		return
+	}
+
+	now := time.Now()
+	scheduledFor := now.Add(account.DeletionGrace())
+	if err := config.DB.Model(user).Updates(map[string]interface{}{
+		"deletion_requested_at":  now,
+		"deletion_scheduled_for": scheduledFor,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":                "Account scheduled for deletion",
+		"deletion_scheduled_for": scheduledFor,
+		"content_policy":         account.ContentPolicy(),
+	})
+}
+
+// CancelAccountDeletion keeps an account that is still in its grace period
+func CancelAccountDeletion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result := config.DB.Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_for IS NOT NULL", userID).
+		Updates(map[string]interface{}{
+			"deletion_requested_at":  nil,
+			"deletion_scheduled_for": nil,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account deletion is not scheduled"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
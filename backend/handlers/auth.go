@@ -3,12 +3,16 @@ package handlers
 import (
 	"gin-quickstart/config"
 	"gin-quickstart/models"
+	"gin-quickstart/revocation"
 	"gin-quickstart/utils"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // RegisterRequest - Signup request body
@@ -131,8 +135,15 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// Refuse attempts while the account is locked after repeated failures
+	if rejectIfLocked(c, &user) {
+		return
+	}
+
 	// Verify password
 	if err := user.CheckPassword(req.Password); err != nil {
+		recordFailedLogin(&user)
+
 		// Same generic error
 		c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Error: "Invalid email or password",
@@ -140,22 +151,72 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// Generate access token (short-lived, 15 minutes)
-	accessToken, err := utils.GenerateAccessToken(user.ID, user.Email, user.Username)
+	if user.FailedLoginAttempts > 0 {
+		config.DB.Model(&user).Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+		})
+	}
+
+	// Suspended accounts cannot sign in
+	if user.IsSuspended(time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":           "Account suspended",
+			"suspended_until": user.SuspendedUntil,
+		})
+		return
+	}
+
+	// With two-factor enabled, hand out a challenge instead of tokens
+	if user.TwoFactorEnabled {
+		mfaToken, err := utils.GenerateMFAToken(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to generate MFA challenge",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+		return
+	}
+
+	issueTokens(c, user)
+}
+
+// issueTokens completes a login by returning a new access/refresh token pair
+func issueTokens(c *gin.Context, user models.User) {
+	accessToken, refreshToken, err := createTokenPair(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: "Failed to generate access token",
+			Error: "Failed to issue tokens",
 		})
 		return
 	}
 
+	// Return both tokens and user info
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"user":          user,
+	})
+}
+
+// createTokenPair generates an access token and a stored refresh token
+func createTokenPair(user models.User) (accessToken string, refreshToken string, err error) {
+	// Generate access token (short-lived, 15 minutes)
+	accessToken, err = utils.GenerateAccessToken(user.ID, user.Email, user.Username)
+	if err != nil {
+		return "", "", err
+	}
+
 	// Generate refresh token (long-lived, 7 days)
 	refreshToken, expiresAt, err := utils.GenerateRefreshToken(user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: "Failed to generate refresh token",
-		})
-		return
+		return "", "", err
 	}
 
 	// Save refresh token to database
@@ -165,18 +226,58 @@ func Login(c *gin.Context) {
 		ExpiresAt: expiresAt,
 	}
 	if err := config.DB.Create(&refreshTokenModel).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: "Failed to save refresh token",
-		})
-		return
+		return "", "", err
 	}
 
-	// Return both tokens and user info
-	c.JSON(http.StatusOK, gin.H{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-		"user":          user,
+	return accessToken, refreshToken, nil
+}
+
+// Account lockout after repeated failed logins
+const (
+	lockoutThreshold = 5              // Failures before the first lock
+	lockoutBase      = time.Minute    // First lock duration, doubled each further failure
+	lockoutMax       = 24 * time.Hour // Longest lock
+)
+
+// rejectIfLocked responds with 429 and Retry-After if the account is locked
+func rejectIfLocked(c *gin.Context, user *models.User) bool {
+	if user.LockedUntil == nil || !user.LockedUntil.After(time.Now()) {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(time.Until(*user.LockedUntil).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed login attempts, please try again later",
+		"retry_after": retryAfter,
 	})
+	return true
+}
+
+// recordFailedLogin counts a failed password and locks the account with
+// exponential backoff once the threshold is reached
+func recordFailedLogin(user *models.User) {
+	// Increment in SQL so concurrent attempts are all counted
+	if err := config.DB.Model(user).
+		UpdateColumn("failed_login_attempts", gorm.Expr("failed_login_attempts + 1")).Error; err != nil {
+		return
+	}
+	config.DB.Select("id", "failed_login_attempts").First(user, user.ID)
+
+	if user.FailedLoginAttempts < lockoutThreshold {
+		return
+	}
+
+	lock := lockoutBase
+	for i := lockoutThreshold; i < user.FailedLoginAttempts && lock < lockoutMax; i++ {
+		lock *= 2
+	}
+	if lock > lockoutMax {
+		lock = lockoutMax
+	}
+
+	lockedUntil := time.Now().Add(lock)
+	config.DB.Model(user).UpdateColumn("locked_until", lockedUntil)
 }
 
 // GetCurrentUser returns the authenticated user's profile
@@ -250,6 +351,13 @@ func RefreshTokenHandler(c *gin.Context) {
 		return
 	}
 
+	if user.IsSuspended(time.Now()) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "Account suspended",
+		})
+		return
+	}
+
 	// Generate new access token
 	accessToken, err := utils.GenerateAccessToken(user.ID, user.Email, user.Username)
 	if err != nil {
@@ -293,18 +401,11 @@ func Logout(c *gin.Context) {
 	}
 
 	// Blacklist the access token
-	blacklistedToken := models.BlacklistedToken{
-		Token:     req.AccessToken,
-		ExpiresAt: claims.ExpiresAt.Time,
-	}
-	if err := config.DB.Create(&blacklistedToken).Error; err != nil {
-		// If token already blacklisted, ignore the error
-		if !strings.Contains(err.Error(), "duplicate") {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error: "Failed to blacklist access token",
-			})
-			return
-		}
+	if err := revocation.RevokeToken(claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to blacklist access token",
+		})
+		return
 	}
 
 	// Delete refresh token from database
@@ -316,3 +417,30 @@ func Logout(c *gin.Context) {
 		"message": "Logged out successfully",
 	})
 }
+
+// LogoutAll revokes every access and refresh token issued to the user so far
+func LogoutAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "User not authenticated",
+		})
+		return
+	}
+
+	now := time.Now()
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return revocation.RevokeUser(tx, userID.(uint), now)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to revoke sessions",
+		})
+		return
+	}
+	revocation.ApplyUser(userID.(uint), now)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out of all sessions",
+	})
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-quickstart/config"
+	"gin-quickstart/counters"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// blockedUserIDs returns the IDs of users the given user has blocked
+func blockedUserIDs(userID uint) []uint {
+	if userID == 0 {
+		return nil
+	}
+
+	var ids []uint
+	config.DB.Model(&models.Block{}).Where("blocker_id = ?", userID).Pluck("blocked_id", &ids)
+	return ids
+}
+
+// mutedUserIDs returns the IDs of users the given user has muted
+func mutedUserIDs(userID uint) []uint {
+	if userID == 0 {
+		return nil
+	}
+
+	var ids []uint
+	config.DB.Model(&models.Mute{}).Where("muter_id = ?", userID).Pluck("muted_id", &ids)
+	return ids
+}
+
+// hasBlocked reports whether blocker has blocked the other user
+func hasBlocked(blockerID, blockedID uint) bool {
+	var count int64
+	config.DB.Model(&models.Block{}).
+		Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Count(&count)
+	return count > 0
+}
+
+// isBlockedEitherWay reports whether either user has blocked the other
+func isBlockedEitherWay(a, b uint) bool {
+	var count int64
+	config.DB.Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count)
+	return count > 0
+}
+
+// viewerID returns the authenticated user's ID, or 0 for anonymous requests
+func viewerID(c *gin.Context) uint {
+	if userID, exists := c.Get("user_id"); exists {
+		return userID.(uint)
+	}
+	return 0
+}
+
+// excludeUsers filters out rows whose column references one of the given users
+func excludeUsers(query *gorm.DB, column string, userIDs []uint) *gorm.DB {
+	if len(userIDs) == 0 {
+		return query
+	}
+	return query.Where(column+" NOT IN ?", userIDs)
+}
+
+// findTargetUser loads the user named in the URL and rejects acting on yourself
+func findTargetUser(c *gin.Context, userID uint, action string) (*models.User, bool) {
+	var target models.User
+	if err := config.DB.Where("username = ?", c.Param("username")).First(&target).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+
+	if target.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot " + action + " yourself"})
+		return nil, false
+	}
+
+	return &target, true
+}
+
+// BlockUser blocks a user and removes follows and follow requests in both directions
+func BlockUser(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	target, ok := findTargetUser(c, userID.(uint), "block")
+	if !ok {
+		return
+	}
+
+	if hasBlocked(userID.(uint), target.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "User already blocked"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		block := models.Block{BlockerID: userID.(uint), BlockedID: target.ID}
+		if err := tx.Create(&block).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("(requester_id = ? AND target_id = ?) OR (requester_id = ? AND target_id = ?)",
+			userID, target.ID, target.ID, userID).
+			Delete(&models.FollowRequest{}).Error; err != nil {
+			return err
+		}
+
+		// Follows in either direction end, and stop counting. The rows are soft
+		// deleted; addFollow brings one back if the pair follow again.
+		for _, pair := range [][2]uint{{userID.(uint), target.ID}, {target.ID, userID.(uint)}} {
+			result := tx.Where("follower_id = ? AND following_id = ?", pair[0], pair[1]).Delete(&models.Follow{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				if err := counters.Follow(tx, pair[0], pair[1], -1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Successfully blocked user"})
+}
+
+// UnblockUser removes a block
+func UnblockUser(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	target, ok := findTargetUser(c, userID.(uint), "unblock")
+	if !ok {
+		return
+	}
+
+	result := config.DB.Where("blocker_id = ? AND blocked_id = ?", userID, target.ID).Delete(&models.Block{})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not blocked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully unblocked user"})
+}
+
+// GetBlockedUsers lists users the authenticated user has blocked
+func GetBlockedUsers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var blocks []models.Block
+	if err := config.DB.Where("blocker_id = ?", userID).
+		Preload("Blocked").
+		Order("created_at DESC").
+		Find(&blocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch blocked users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocks": blocks, "total": len(blocks)})
+}
+
+// MuteUser hides a user's posts from the authenticated user's feeds
+func MuteUser(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	target, ok := findTargetUser(c, userID.(uint), "mute")
+	if !ok {
+		return
+	}
+
+	var existing models.Mute
+	if err := config.DB.Where("muter_id = ? AND muted_id = ?", userID, target.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User already muted"})
+		return
+	}
+
+	mute := models.Mute{MuterID: userID.(uint), MutedID: target.ID}
+	if err := config.DB.Create(&mute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mute user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Successfully muted user"})
+}
+
+// UnmuteUser removes a mute
+func UnmuteUser(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	target, ok := findTargetUser(c, userID.(uint), "unmute")
+	if !ok {
+		return
+	}
+
+	result := config.DB.Where("muter_id = ? AND muted_id = ?", userID, target.ID).Delete(&models.Mute{})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not muted"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully unmuted user"})
+}
+
+// GetMutedUsers lists users the authenticated user has muted
+func GetMutedUsers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var mutes []models.Mute
+	if err := config.DB.Where("muter_id = ?", userID).
+		Preload("Muted").
+		Order("created_at DESC").
+		Find(&mutes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch muted users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mutes": mutes, "total": len(mutes)})
+}
@@ -5,12 +5,75 @@ import (
 	"strconv"
 
 	"gin-quickstart/config"
+	"gin-quickstart/counters"
 	"gin-quickstart/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// AddBookmark adds a post to user's bookmarks
+// readingList returns the user's built-in list, creating it on first use
+func readingList(userID uint) (*models.BookmarkList, error) {
+	list := models.BookmarkList{UserID: userID, Name: models.ReadingListName, Builtin: true}
+	err := config.DB.Where("user_id = ? AND builtin = ?", userID, true).FirstOrCreate(&list).Error
+	return &list, err
+}
+
+// addToList puts a post at the top of a list
+func addToList(list *models.BookmarkList, postID uint, note string) (*models.Bookmark, error) {
+	bookmark := models.Bookmark{
+		UserID: list.UserID,
+		ListID: &list.ID,
+		PostID: postID,
+		Note:   note,
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Bookmark{}).Where("list_id = ?", list.ID).
+			Update("position", gorm.Expr("position + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&bookmark).Error; err != nil {
+			return err
+		}
+		return counters.Bookmarked(tx, list.UserID, postID)
+	})
+	return &bookmark, err
+}
+
+// removeFromList takes a post out of a list, reporting whether it was there
+func removeFromList(list *models.BookmarkList, postID uint) (bool, error) {
+	removed := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("list_id = ? AND post_id = ?", list.ID, postID).Delete(&models.Bookmark{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		removed = true
+		return counters.Unbookmarked(tx, list.UserID, []uint{postID})
+	})
+	return removed, err
+}
+
+// findBookmarkablePost loads the post named by the postId URL parameter and
+// checks the user may read it
+func findBookmarkablePost(c *gin.Context, userID uint) (*models.Post, bool) {
+	postID, err := strconv.ParseUint(c.Param("postId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return nil, false
+	}
+
+	var post models.Post
+	if err := config.DB.Preload("Author").First(&post, postID).Error; err != nil ||
+		!post.Published || !canViewPostsBy(userID, &post.Author) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return nil, false
+	}
+	return &post, true
+}
+
+// AddBookmark adds a post to user's reading list
 func AddBookmark(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -18,33 +81,26 @@ func AddBookmark(c *gin.Context) {
 		return
 	}
 
-	postIDStr := c.Param("postId")
-	postID, err := strconv.ParseUint(postIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+	post, ok := findBookmarkablePost(c, userID.(uint))
+	if !ok {
 		return
 	}
 
-	// Check if post exists
-	var post models.Post
-	if err := config.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	list, err := readingList(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add bookmark"})
 		return
 	}
 
 	// Check if already bookmarked
 	var existingBookmark models.Bookmark
-	if err := config.DB.Where("user_id = ? AND post_id = ?", userID, postID).First(&existingBookmark).Error; err == nil {
+	if err := config.DB.Where("list_id = ? AND post_id = ?", list.ID, post.ID).First(&existingBookmark).Error; err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Post already bookmarked"})
 		return
 	}
 
-	bookmark := models.Bookmark{
-		UserID: userID.(uint),
-		PostID: uint(postID),
-	}
-
-	if err := config.DB.Create(&bookmark).Error; err != nil {
+	bookmark, err := addToList(list, post.ID, "")
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add bookmark"})
 		return
 	}
@@ -52,7 +108,7 @@ func AddBookmark(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "Post bookmarked successfully", "bookmark": bookmark})
 }
 
-// RemoveBookmark removes a post from user's bookmarks
+// RemoveBookmark removes a post from user's reading list
 func RemoveBookmark(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -67,8 +123,18 @@ func RemoveBookmark(c *gin.Context) {
 		return
 	}
 
-	result := config.DB.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.Bookmark{})
-	if result.RowsAffected == 0 {
+	list, err := readingList(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark"})
+		return
+	}
+
+	removed, err := removeFromList(list, uint(postID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark"})
+		return
+	}
+	if !removed {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
 		return
 	}
@@ -76,7 +142,7 @@ func RemoveBookmark(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed successfully"})
 }
 
-// GetBookmarks returns all bookmarks for the authenticated user
+// GetBookmarks returns the authenticated user's reading list with pagination
 func GetBookmarks(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -84,20 +150,39 @@ func GetBookmarks(c *gin.Context) {
 		return
 	}
 
-	var bookmarks []models.Bookmark
-	if err := config.DB.Where("user_id = ?", userID).
-		Preload("Post").
-		Preload("Post.Author").
-		Order("created_at DESC").
-		Find(&bookmarks).Error; err != nil {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	list, err := readingList(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks"})
+		return
+	}
+
+	bookmarks, total, err := listItems(list, userID.(uint), page, limit)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"bookmarks": bookmarks, "total": len(bookmarks)})
+	c.JSON(http.StatusOK, gin.H{
+		"bookmarks": bookmarks,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+		"list_id":   list.ID,
+	})
 }
 
-// CheckBookmark checks if a post is bookmarked by the user
+// CheckBookmark checks if a post is in the user's reading list, and which of
+// their lists contain it
 func CheckBookmark(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -112,11 +197,19 @@ func CheckBookmark(c *gin.Context) {
 		return
 	}
 
-	var bookmark models.Bookmark
-	if err := config.DB.Where("user_id = ? AND post_id = ?", userID, postID).First(&bookmark).Error; err != nil {
-		c.JSON(http.StatusOK, gin.H{"bookmarked": false})
-		return
+	var lists []models.BookmarkList
+	config.DB.Joins("JOIN bookmarks ON bookmarks.list_id = bookmark_lists.id AND bookmarks.deleted_at IS NULL").
+		Where("bookmarks.user_id = ? AND bookmarks.post_id = ?", userID, postID).
+		Find(&lists)
+
+	bookmarked := false
+	listIDs := make([]uint, len(lists))
+	for i, l := range lists {
+		listIDs[i] = l.ID
+		if l.Builtin {
+			bookmarked = true
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"bookmarked": true})
+	c.JSON(http.StatusOK, gin.H{"bookmarked": bookmarked, "list_ids": listIDs})
 }
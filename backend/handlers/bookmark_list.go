@@ -0,0 +1,583 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gin-quickstart/config"
+	"gin-quickstart/counters"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBookmarkLists caps how many lists one user may create
+const maxBookmarkLists = 100
+
+// BookmarkListSummary is a list card with its size
+type BookmarkListSummary struct {
+	models.BookmarkList
+	ItemCount int64  `json:"item_count"`
+	ShareURL  string `json:"share_url,omitempty"`
+}
+
+// listShareURL is the frontend address of a public list
+func listShareURL(list *models.BookmarkList) string {
+	if !list.Public {
+		return ""
+	}
+	return appURL() + "/lists/" + strconv.FormatUint(uint64(list.ID), 10)
+}
+
+// summarizeLists adds item counts and share links to lists. Counts cover the
+// same bookmarks as listItems, so a card matches the list page total.
+func summarizeLists(lists []models.BookmarkList, viewer uint) []BookmarkListSummary {
+	ids := make([]uint, len(lists))
+	for i, l := range lists {
+		ids[i] = l.ID
+	}
+
+	counts := make(map[uint]int64)
+	if len(ids) > 0 {
+		var rows []struct {
+			ListID uint
+			Count  int64
+		}
+		listBookmarks(viewer).
+			Select("bookmarks.list_id, COUNT(*) AS count").
+			Where("bookmarks.list_id IN ?", ids).
+			Group("bookmarks.list_id").
+			Scan(&rows)
+		for _, r := range rows {
+			counts[r.ListID] = r.Count
+		}
+	}
+
+	summaries := make([]BookmarkListSummary, len(lists))
+	for i := range lists {
+		summaries[i] = BookmarkListSummary{
+			BookmarkList: lists[i],
+			ItemCount:    counts[lists[i].ID],
+			ShareURL:     listShareURL(&lists[i]),
+		}
+	}
+	return summaries
+}
+
+// canViewList reports whether the viewer may see a list: their own, or a
+// public list whose owner's posts they can see
+func canViewList(viewer uint, list *models.BookmarkList) bool {
+	if list.UserID == viewer {
+		return true
+	}
+	return list.Public && canViewPostsBy(viewer, &list.User) && !isBlockedEitherWay(viewer, list.UserID)
+}
+
+// listBookmarks selects the bookmarks whose posts the viewer can see,
+// skipping posts that were deleted, unpublished or are hidden from them
+func listBookmarks(viewer uint) *gorm.DB {
+	query := config.DB.Model(&models.Bookmark{}).
+		Joins("JOIN posts ON posts.id = bookmarks.post_id AND posts.deleted_at IS NULL").
+		Where("posts.published = ?", true)
+	return visibleAuthors(query, "posts.author_id", viewer)
+}
+
+// listItems returns a page of a list's bookmarks with their posts
+func listItems(list *models.BookmarkList, viewer uint, page, limit int) ([]models.Bookmark, int64, error) {
+	query := listBookmarks(viewer).Where("bookmarks.list_id = ?", list.ID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []models.Bookmark
+	err := query.Preload("Post").Preload("Post.Author").
+		Order("bookmarks.position ASC, bookmarks.id DESC").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&items).Error
+	return items, total, err
+}
+
+// findOwnList loads the list named in the URL and checks the caller owns it
+func findOwnList(c *gin.Context) (*models.BookmarkList, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	var list models.BookmarkList
+	if err := config.DB.First(&list, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return nil, false
+	}
+
+	if list.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own lists"})
+		return nil, false
+	}
+
+	return &list, true
+}
+
+// GetMyBookmarkLists returns the authenticated user's lists, reading list first
+func GetMyBookmarkLists(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if _, err := readingList(userID.(uint)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lists"})
+		return
+	}
+
+	var lists []models.BookmarkList
+	if err := config.DB.Where("user_id = ?", userID).Order("builtin DESC, created_at ASC").Find(&lists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lists": summarizeLists(lists, userID.(uint))})
+}
+
+// GetUserBookmarkLists returns a user's public lists
+func GetUserBookmarkLists(c *gin.Context) {
+	var user models.User
+	if err := config.DB.Where("username = ?", c.Param("username")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	viewer := viewerID(c)
+	if !canViewPostsBy(viewer, &user) || isBlockedEitherWay(viewer, user.ID) {
+		c.JSON(http.StatusOK, gin.H{"lists": []BookmarkListSummary{}, "private": true})
+		return
+	}
+
+	var lists []models.BookmarkList
+	if err := config.DB.Where("user_id = ? AND public = ?", user.ID, true).Order("created_at ASC").Find(&lists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lists": summarizeLists(lists, viewer)})
+}
+
+// GetFollowedBookmarkLists returns the public lists the authenticated user follows
+func GetFollowedBookmarkLists(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var lists []models.BookmarkList
+	if err := config.DB.Preload("User").
+		Joins("JOIN bookmark_list_follows ON bookmark_list_follows.list_id = bookmark_lists.id").
+		Where("bookmark_list_follows.user_id = ? AND bookmark_lists.public = ?", userID, true).
+		Order("bookmark_list_follows.created_at DESC").
+		Find(&lists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lists": summarizeLists(lists, userID.(uint))})
+}
+
+// CreateBookmarkList creates a named list
+func CreateBookmarkList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var input struct {
+		Name        string `json:"name" binding:"required,max=100"`
+		Description string `json:"description"`
+		Public      bool   `json:"public"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.BookmarkList{}).Where("user_id = ?", userID).Count(&count)
+	if count >= maxBookmarkLists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "List limit reached; delete an unused list first"})
+		return
+	}
+
+	list := models.BookmarkList{
+		UserID:      userID.(uint),
+		Name:        input.Name,
+		Description: input.Description,
+		Public:      input.Public,
+	}
+
+	if err := config.DB.Create(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create list"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"list": summarizeLists([]models.BookmarkList{list}, userID.(uint))[0]})
+}
+
+// GetBookmarkList returns a list with a page of its posts. Public lists can
+// be opened by anyone with the link.
+func GetBookmarkList(c *gin.Context) {
+	viewer := viewerID(c)
+
+	var list models.BookmarkList
+	if err := config.DB.Preload("User").First(&list, c.Param("id")).Error; err != nil || !canViewList(viewer, &list) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	items, total, err := listItems(&list, viewer, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch list"})
+		return
+	}
+
+	var followerCount int64
+	config.DB.Model(&models.BookmarkListFollow{}).Where("list_id = ?", list.ID).Count(&followerCount)
+
+	following := false
+	if viewer != 0 && viewer != list.UserID {
+		var count int64
+		config.DB.Model(&models.BookmarkListFollow{}).Where("list_id = ? AND user_id = ?", list.ID, viewer).Count(&count)
+		following = count > 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"list":           list,
+		"share_url":      listShareURL(&list),
+		"items":          items,
+		"total":          total,
+		"page":           page,
+		"limit":          limit,
+		"follower_count": followerCount,
+		"following":      following,
+	})
+}
+
+// UpdateBookmarkList changes a list's name, description or visibility. The
+// reading list keeps its name.
+func UpdateBookmarkList(c *gin.Context) {
+	list, ok := findOwnList(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Public      *bool   `json:"public"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if list.Builtin && name != list.Name {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The reading list cannot be renamed"})
+			return
+		}
+		if name == "" || len(name) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name must be 1-100 characters"})
+			return
+		}
+		list.Name = name
+	}
+
+	if input.Description != nil {
+		list.Description = *input.Description
+	}
+
+	if input.Public != nil {
+		list.Public = *input.Public
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(list).Error; err != nil {
+			return err
+		}
+		// Followers only make sense for public lists
+		if !list.Public {
+			return tx.Where("list_id = ?", list.ID).Delete(&models.BookmarkListFollow{}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"list": summarizeLists([]models.BookmarkList{*list}, list.UserID)[0]})
+}
+
+// DeleteBookmarkList deletes a list and its bookmarks. The reading list
+// cannot be deleted.
+func DeleteBookmarkList(c *gin.Context) {
+	list, ok := findOwnList(c)
+	if !ok {
+		return
+	}
+
+	if list.Builtin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The reading list cannot be deleted"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var postIDs []uint
+		if err := tx.Model(&models.Bookmark{}).Where("list_id = ?", list.ID).Pluck("post_id", &postIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("list_id = ?", list.ID).Delete(&models.Bookmark{}).Error; err != nil {
+			return err
+		}
+		if err := counters.Unbookmarked(tx, list.UserID, postIDs); err != nil {
+			return err
+		}
+		if err := tx.Where("list_id = ?", list.ID).Delete(&models.BookmarkListFollow{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(list).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "List deleted successfully"})
+}
+
+// AddBookmarkListItem puts a post at the top of a list, with an optional note
+func AddBookmarkListItem(c *gin.Context) {
+	list, ok := findOwnList(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PostID uint   `json:"post_id" binding:"required"`
+		Note   string `json:"note"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Post ID is required"})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.Preload("Author").First(&post, input.PostID).Error; err != nil ||
+		!post.Published || !canViewPostsBy(list.UserID, &post.Author) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	var existing models.Bookmark
+	if err := config.DB.Where("list_id = ? AND post_id = ?", list.ID, post.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Post is already in this list"})
+		return
+	}
+
+	bookmark, err := addToList(list, post.ID, input.Note)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add post to list"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"item": bookmark})
+}
+
+// UpdateBookmarkListItem changes the note on a post in a list
+func UpdateBookmarkListItem(c *gin.Context) {
+	list, ok := findOwnList(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Note string `json:"note"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var bookmark models.Bookmark
+	if err := config.DB.Where("list_id = ? AND post_id = ?", list.ID, c.Param("postId")).First(&bookmark).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post is not in this list"})
+		return
+	}
+
+	if err := config.DB.Model(&bookmark).Update("note", input.Note).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update note"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"item": bookmark})
+}
+
+// RemoveBookmarkListItem takes a post out of a list
+func RemoveBookmarkListItem(c *gin.Context) {
+	list, ok := findOwnList(c)
+	if !ok {
+		return
+	}
+
+	postID, err := strconv.ParseUint(c.Param("postId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	removed, err := removeFromList(list, uint(postID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove post from list"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post is not in this list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post removed from list"})
+}
+
+// ReorderBookmarkList sets the order of a list's posts. The request must
+// list every post in the list exactly once.
+func ReorderBookmarkList(c *gin.Context) {
+	list, ok := findOwnList(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PostIDs []uint `json:"post_ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Post IDs are required"})
+		return
+	}
+
+	var current []uint
+	config.DB.Model(&models.Bookmark{}).Where("list_id = ?", list.ID).Pluck("post_id", &current)
+
+	inList := make(map[uint]bool, len(current))
+	for _, id := range current {
+		inList[id] = true
+	}
+
+	listed := make(map[uint]bool, len(input.PostIDs))
+	for _, id := range input.PostIDs {
+		if !inList[id] || listed[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Post IDs must list every post in the list exactly once"})
+			return
+		}
+		listed[id] = true
+	}
+	if len(listed) != len(inList) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Post IDs must list every post in the list exactly once"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range input.PostIDs {
+			if err := tx.Model(&models.Bookmark{}).
+				Where("list_id = ? AND post_id = ?", list.ID, id).
+				Update("position", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "List reordered"})
+}
+
+// FollowBookmarkList follows someone else's public list
+func FollowBookmarkList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var list models.BookmarkList
+	if err := config.DB.Preload("User").First(&list, c.Param("id")).Error; err != nil || !canViewList(uid, &list) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return
+	}
+
+	if list.UserID == uid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot follow your own list"})
+		return
+	}
+
+	var existing models.BookmarkListFollow
+	if err := config.DB.Where("user_id = ? AND list_id = ?", uid, list.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Already following this list"})
+		return
+	}
+
+	if err := config.DB.Create(&models.BookmarkListFollow{UserID: uid, ListID: list.ID}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow list"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Successfully followed list"})
+}
+
+// UnfollowBookmarkList unfollows a list
+func UnfollowBookmarkList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result := config.DB.Where("user_id = ? AND list_id = ?", userID, c.Param("id")).Delete(&models.BookmarkListFollow{})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not following this list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully unfollowed list"})
+}
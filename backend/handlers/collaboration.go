@@ -0,0 +1,545 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+	"gin-quickstart/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// How a user may work on a post, strongest first
+const (
+	accessAuthor   = "author"
+	accessEditor   = "editor" // owner or editor of the publication the post is submitted to
+	accessCoAuthor = models.CollaboratorRoleCoAuthor
+	accessReviewer = models.CollaboratorRoleReviewer
+)
+
+// Share link lifetime bounds
+const (
+	defaultShareLinkHours = 72
+	maxShareLinkHours     = 30 * 24
+)
+
+// postAccess returns how the user may work on a post: as its author, a
+// publication editor, a co-author, a reviewer, or not at all ("")
+func postAccess(userID uint, post *models.Post) string {
+	if userID == 0 {
+		return ""
+	}
+	if post.AuthorID == userID {
+		return accessAuthor
+	}
+	if canEditForPublication(userID, post.PublicationID) {
+		return accessEditor
+	}
+
+	var collaborator models.PostCollaborator
+	if err := config.DB.Where("post_id = ? AND user_id = ?", post.ID, userID).First(&collaborator).Error; err == nil {
+		return collaborator.Role
+	}
+	return ""
+}
+
+// canEditPost reports whether an access level allows changing the post
+func canEditPost(access string) bool {
+	return access == accessAuthor || access == accessEditor || access == accessCoAuthor
+}
+
+// findPostForWork loads the post named in the URL (by id, or by slug on GET
+// routes) and checks the caller has one of the given access levels to it
+func findPostForWork(c *gin.Context, allowed ...string) (*models.Post, uint, string, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, 0, "", false
+	}
+	uid := userID.(uint)
+
+	var post models.Post
+	query := config.DB
+	if id := c.Param("id"); id != "" {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("slug = ?", c.Param("slug"))
+	}
+	if err := query.First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return nil, 0, "", false
+	}
+
+	access := postAccess(uid, &post)
+	for _, a := range allowed {
+		if access == a {
+			return &post, uid, access, true
+		}
+	}
+
+	// Don't reveal drafts to people who can't see them
+	if access == "" && !post.Published {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	} else {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to do that on this post"})
+	}
+	return nil, 0, "", false
+}
+
+// GetCollaborators lists a post's co-authors and reviewers
+func GetCollaborators(c *gin.Context) {
+	post, _, _, ok := findPostForWork(c, accessAuthor, accessEditor, accessCoAuthor, accessReviewer)
+	if !ok {
+		return
+	}
+
+	var collaborators []models.PostCollaborator
+	if err := config.DB.Preload("User").Where("post_id = ?", post.ID).Order("created_at ASC").Find(&collaborators).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collaborators"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collaborators": collaborators})
+}
+
+// AddCollaborator invites a user to co-author or review a post
+func AddCollaborator(c *gin.Context) {
+	post, uid, _, ok := findPostForWork(c, accessAuthor)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Username string `json:"username" binding:"required"`
+		Role     string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username and role are required"})
+		return
+	}
+
+	if !models.IsValidCollaboratorRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be coauthor or reviewer"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("username = ?", input.Username).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.ID == uid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You are already the author"})
+		return
+	}
+
+	if isBlockedEitherWay(uid, user.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot add this user"})
+		return
+	}
+
+	var existing models.PostCollaborator
+	if err := config.DB.Where("post_id = ? AND user_id = ?", post.ID, user.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already a collaborator"})
+		return
+	}
+
+	collaborator := models.PostCollaborator{
+		PostID:      post.ID,
+		UserID:      user.ID,
+		Role:        input.Role,
+		InvitedByID: uid,
+	}
+	if err := config.DB.Create(&collaborator).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add collaborator"})
+		return
+	}
+
+	notify(user.ID, uid, "collaborator_added", &post.ID, nil)
+
+	config.DB.Preload("User").First(&collaborator, collaborator.ID)
+
+	c.JSON(http.StatusCreated, gin.H{"collaborator": collaborator})
+}
+
+// findCollaborator loads the collaborator named in the URL
+func findCollaborator(c *gin.Context, post *models.Post) (*models.PostCollaborator, bool) {
+	var collaborator models.PostCollaborator
+	if err := config.DB.Joins("JOIN users ON users.id = post_collaborators.user_id").
+		Where("post_collaborators.post_id = ? AND users.username = ?", post.ID, c.Param("username")).
+		First(&collaborator).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collaborator not found"})
+		return nil, false
+	}
+	return &collaborator, true
+}
+
+// UpdateCollaborator changes a collaborator's role
+func UpdateCollaborator(c *gin.Context) {
+	post, _, _, ok := findPostForWork(c, accessAuthor)
+	if !ok {
+		return
+	}
+
+	collaborator, ok := findCollaborator(c, post)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil || !models.IsValidCollaboratorRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be coauthor or reviewer"})
+		return
+	}
+
+	if err := config.DB.Model(collaborator).Update("role", input.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collaborator"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collaborator": collaborator})
+}
+
+// RemoveCollaborator takes a collaborator off a post. The author can remove
+// anyone; collaborators can remove themselves.
+func RemoveCollaborator(c *gin.Context) {
+	post, uid, access, ok := findPostForWork(c, accessAuthor, accessCoAuthor, accessReviewer)
+	if !ok {
+		return
+	}
+
+	collaborator, ok := findCollaborator(c, post)
+	if !ok {
+		return
+	}
+
+	if access != accessAuthor && collaborator.UserID != uid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only remove yourself"})
+		return
+	}
+
+	if err := config.DB.Delete(collaborator).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove collaborator"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collaborator removed"})
+}
+
+// GetSharedPosts returns posts the authenticated user collaborates on
+func GetSharedPosts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var collaborations []models.PostCollaborator
+	if err := config.DB.Preload("Post").Preload("Post.Author").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&collaborations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shared posts"})
+		return
+	}
+
+	type SharedPost struct {
+		models.Post
+		Role string `json:"role"`
+	}
+
+	posts := make([]SharedPost, 0, len(collaborations))
+	for _, collab := range collaborations {
+		if collab.Post.ID == 0 {
+			continue
+		}
+		posts = append(posts, SharedPost{Post: collab.Post, Role: collab.Role})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"posts": posts})
+}
+
+// CreateShareLink creates a secret preview link for a draft. The link is
+// only returned in this response; afterwards only its hash is kept.
+func CreateShareLink(c *gin.Context) {
+	post, uid, _, ok := findPostForWork(c, accessAuthor, accessEditor, accessCoAuthor)
+	if !ok {
+		return
+	}
+
+	if post.Published {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only drafts can be shared for preview"})
+		return
+	}
+
+	var input struct {
+		ExpiresInHours int `json:"expires_in_hours"`
+	}
+	c.ShouldBindJSON(&input)
+
+	if input.ExpiresInHours == 0 {
+		input.ExpiresInHours = defaultShareLinkHours
+	}
+	if input.ExpiresInHours < 1 || input.ExpiresInHours > maxShareLinkHours {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_hours must be between 1 and 720"})
+		return
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate link"})
+		return
+	}
+
+	link := models.DraftShareLink{
+		PostID:      post.ID,
+		CreatedByID: uid,
+		TokenHash:   utils.HashToken(token),
+		Hint:        token[len(token)-4:],
+		ExpiresAt:   time.Now().Add(time.Duration(input.ExpiresInHours) * time.Hour),
+	}
+	if err := config.DB.Create(&link).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"link":  link,
+		"token": token,
+		"url":   appURL() + "/preview/" + token,
+	})
+}
+
+// GetShareLinks lists a draft's preview links, including expired and revoked ones
+func GetShareLinks(c *gin.Context) {
+	post, _, _, ok := findPostForWork(c, accessAuthor, accessEditor, accessCoAuthor)
+	if !ok {
+		return
+	}
+
+	var links []models.DraftShareLink
+	if err := config.DB.Where("post_id = ?", post.ID).Order("created_at DESC").Find(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch links"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+// RevokeShareLink stops a preview link from working
+func RevokeShareLink(c *gin.Context) {
+	post, _, _, ok := findPostForWork(c, accessAuthor, accessEditor, accessCoAuthor)
+	if !ok {
+		return
+	}
+
+	result := config.DB.Model(&models.DraftShareLink{}).
+		Where("id = ? AND post_id = ? AND revoked_at IS NULL", c.Param("linkId"), post.ID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke link"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Link revoked"})
+}
+
+// GetDraftPreview shows a draft to anyone holding a valid preview link.
+// Review notes are never included.
+func GetDraftPreview(c *gin.Context) {
+	token := strings.TrimSpace(c.Param("token"))
+
+	var link models.DraftShareLink
+	if err := config.DB.Where("token_hash = ?", utils.HashToken(token)).First(&link).Error; err != nil || !link.IsActive(time.Now()) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview link is invalid or has expired"})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.Preload("Author").First(&post, link.PostID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview link is invalid or has expired"})
+		return
+	}
+
+	// Once live, the post is read at its public URL
+	if post.Published {
+		c.JSON(http.StatusGone, gin.H{"error": "Post has been published", "slug": post.Slug})
+		return
+	}
+
+	config.DB.Model(&link).Update("view_count", gorm.Expr("view_count + 1"))
+
+	c.JSON(http.StatusOK, gin.H{
+		"post":       post,
+		"preview":    true,
+		"expires_at": link.ExpiresAt,
+	})
+}
+
+// GetReviewNotes lists the review notes on a post. Resolved notes are
+// included with ?resolved=true.
+func GetReviewNotes(c *gin.Context) {
+	post, _, _, ok := findPostForWork(c, accessAuthor, accessEditor, accessCoAuthor, accessReviewer)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Preload("User").Where("post_id = ?", post.ID)
+	if c.Query("resolved") != "true" {
+		query = query.Where("resolved = ?", false)
+	}
+
+	var notes []models.ReviewNote
+	if err := query.Order("\"offset\" ASC, created_at ASC").Find(&notes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch review notes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notes": notes})
+}
+
+// CreateReviewNote adds a private note to a post, optionally anchored to a
+// quoted passage
+func CreateReviewNote(c *gin.Context) {
+	post, uid, _, ok := findPostForWork(c, accessAuthor, accessEditor, accessCoAuthor, accessReviewer)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Content string `json:"content" binding:"required"`
+		Quote   string `json:"quote"`
+		Offset  int    `json:"offset"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content is required"})
+		return
+	}
+
+	if input.Offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Offset cannot be negative"})
+		return
+	}
+
+	note := models.ReviewNote{
+		PostID:  post.ID,
+		UserID:  uid,
+		Content: input.Content,
+		Quote:   input.Quote,
+		Offset:  input.Offset,
+	}
+	if err := config.DB.Create(&note).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review note"})
+		return
+	}
+
+	// Let the author and co-authors know
+	notify(post.AuthorID, uid, "review_note", &post.ID, nil)
+	var coAuthorIDs []uint
+	config.DB.Model(&models.PostCollaborator{}).
+		Where("post_id = ? AND role = ?", post.ID, models.CollaboratorRoleCoAuthor).
+		Pluck("user_id", &coAuthorIDs)
+	for _, id := range coAuthorIDs {
+		notify(id, uid, "review_note", &post.ID, nil)
+	}
+
+	config.DB.Preload("User").First(&note, note.ID)
+
+	c.JSON(http.StatusCreated, gin.H{"note": note})
+}
+
+// findReviewNote loads the note named in the URL
+func findReviewNote(c *gin.Context, post *models.Post) (*models.ReviewNote, bool) {
+	var note models.ReviewNote
+	if err := config.DB.Where("id = ? AND post_id = ?", c.Param("noteId"), post.ID).First(&note).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review note not found"})
+		return nil, false
+	}
+	return &note, true
+}
+
+// UpdateReviewNote edits a note's text (its writer only) or marks it
+// resolved (anyone working on the post)
+func UpdateReviewNote(c *gin.Context) {
+	post, uid, _, ok := findPostForWork(c, accessAuthor, accessEditor, accessCoAuthor, accessReviewer)
+	if !ok {
+		return
+	}
+
+	note, ok := findReviewNote(c, post)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Content  *string `json:"content"`
+		Resolved *bool   `json:"resolved"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Content != nil {
+		if note.UserID != uid {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own notes"})
+			return
+		}
+		if *input.Content == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Content cannot be empty"})
+			return
+		}
+		note.Content = *input.Content
+	}
+
+	if input.Resolved != nil {
+		note.Resolved = *input.Resolved
+	}
+
+	if err := config.DB.Save(note).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review note"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"note": note})
+}
+
+// DeleteReviewNote deletes a note. Its writer and the post's author may delete it.
+func DeleteReviewNote(c *gin.Context) {
+	post, uid, access, ok := findPostForWork(c, accessAuthor, accessEditor, accessCoAuthor, accessReviewer)
+	if !ok {
+		return
+	}
+
+	note, ok := findReviewNote(c, post)
+	if !ok {
+		return
+	}
+
+	if note.UserID != uid && access != accessAuthor {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own notes"})
+		return
+	}
+
+	if err := config.DB.Delete(note).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review note"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review note deleted"})
+}
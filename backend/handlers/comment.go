@@ -3,13 +3,52 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"gin-quickstart/config"
+	"gin-quickstart/counters"
 	"gin-quickstart/models"
+	"gin-quickstart/realtime"
+	"gin-quickstart/webhooks"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// commentExcerptLength caps the comment text pushed to streams, in characters
+const commentExcerptLength = 280
+
+// commentEvent is a new comment pushed to a post's stream. Like
+// notificationEvent it stays well under pg_notify's 8000-byte limit, so long
+// comments are cut to an excerpt; clients fetch the rest when Truncated is set.
+type commentEvent struct {
+	ID        uint       `json:"id"`
+	PostID    uint       `json:"post_id"`
+	ParentID  *uint      `json:"parent_id"`
+	Excerpt   string     `json:"excerpt"`
+	Truncated bool       `json:"truncated"`
+	CreatedAt time.Time  `json:"created_at"`
+	Author    eventActor `json:"author"`
+}
+
+// newCommentEvent builds the stream event for a comment with its User loaded
+func newCommentEvent(comment *models.Comment) commentEvent {
+	excerpt := []rune(comment.Content)
+	truncated := len(excerpt) > commentExcerptLength
+	if truncated {
+		excerpt = excerpt[:commentExcerptLength]
+	}
+	return commentEvent{
+		ID:        comment.ID,
+		PostID:    comment.PostID,
+		ParentID:  comment.ParentID,
+		Excerpt:   string(excerpt),
+		Truncated: truncated,
+		CreatedAt: comment.CreatedAt,
+		Author:    eventActor{ID: comment.User.ID, Username: comment.User.Username},
+	}
+}
+
 // CreateComment creates a new comment on a post
 func CreateComment(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -25,10 +64,14 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
-	// Check if post exists
-	var post models.Post
-	if err := config.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	post, ok := findViewablePost(c, postID)
+	if !ok {
+		return
+	}
+
+	// Users blocked by the author cannot comment on their posts
+	if hasBlocked(post.AuthorID, userID.(uint)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot comment on this post"})
 		return
 	}
 
@@ -43,12 +86,17 @@ func CreateComment(c *gin.Context) {
 	}
 
 	// If replying to a comment, verify parent exists
+	var parentComment models.Comment
 	if input.ParentID != nil {
-		var parentComment models.Comment
-		if err := config.DB.First(&parentComment, *input.ParentID).Error; err != nil {
+		if err := config.DB.Where("post_id = ?", post.ID).First(&parentComment, *input.ParentID).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Parent comment not found"})
 			return
 		}
+
+		if hasBlocked(parentComment.UserID, userID.(uint)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You cannot reply to this comment"})
+			return
+		}
 	}
 
 	comment := models.Comment{
@@ -58,7 +106,13 @@ func CreateComment(c *gin.Context) {
 		Content:  input.Content,
 	}
 
-	if err := config.DB.Create(&comment).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		return counters.Comments(tx, comment.PostID, 1)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 		return
 	}
@@ -66,10 +120,19 @@ func CreateComment(c *gin.Context) {
 	// Preload user for response
 	config.DB.Preload("User").First(&comment, comment.ID)
 
+	// Push the comment to viewers of the post and notify the people involved
+	realtime.Publish(realtime.PostTopic(post.ID), "comment.created", newCommentEvent(&comment))
+	notify(post.AuthorID, comment.UserID, "comment", &post.ID, &comment.ID)
+	if input.ParentID != nil && parentComment.UserID != post.AuthorID {
+		notify(parentComment.UserID, comment.UserID, "reply", &post.ID, &comment.ID)
+	}
+	webhooks.Emit(webhooks.EventCommentCreated, post.AuthorID, newWebhookComment(&comment))
+
 	c.JSON(http.StatusCreated, gin.H{"comment": comment})
 }
 
-// GetPostComments returns all comments for a post
+// GetPostComments returns all comments for a post, newest first or with
+// ?sort=top by claps
 func GetPostComments(c *gin.Context) {
 	postIDStr := c.Param("postId")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
@@ -78,20 +141,34 @@ func GetPostComments(c *gin.Context) {
 		return
 	}
 
-	// Check if post exists
-	var post models.Post
-	if err := config.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	if _, ok := findViewablePost(c, postID); !ok {
 		return
 	}
 
+	// Hide comments from users the viewer has blocked
+	viewer := viewerID(c)
+	blocked := blockedUserIDs(viewer)
+
+	top := c.Query("sort") == "top"
+	orderBy := "created_at DESC"
+	if top {
+		orderBy = "clap_count DESC, created_at DESC"
+	}
+
 	// Get top-level comments with replies
 	var comments []models.Comment
-	if err := config.DB.Where("post_id = ? AND parent_id IS NULL", postID).
+	query := excludeUsers(config.DB.Where("post_id = ? AND parent_id IS NULL", postID), "user_id", blocked)
+	if err := query.
 		Preload("User").
-		Preload("Replies").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			db = excludeUsers(db, "user_id", blocked)
+			if top {
+				db = db.Order(orderBy)
+			}
+			return db
+		}).
 		Preload("Replies.User").
-		Order("created_at DESC").
+		Order(orderBy).
 		Find(&comments).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
 		return
@@ -99,9 +176,9 @@ func GetPostComments(c *gin.Context) {
 
 	// Count total comments
 	var total int64
-	config.DB.Model(&models.Comment{}).Where("post_id = ?", postID).Count(&total)
+	excludeUsers(config.DB.Model(&models.Comment{}).Where("post_id = ?", postID), "user_id", blocked).Count(&total)
 
-	c.JSON(http.StatusOK, gin.H{"comments": comments, "total": total})
+	c.JSON(http.StatusOK, gin.H{"comments": comments, "total": total, "my_claps": viewerCommentClaps(viewer, comments)})
 }
 
 // UpdateComment updates a comment
@@ -141,7 +218,7 @@ func UpdateComment(c *gin.Context) {
 	}
 
 	comment.Content = input.Content
-	if err := config.DB.Save(&comment).Error; err != nil {
+	if err := config.DB.Omit(models.CommentCounters...).Save(&comment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
 		return
 	}
@@ -179,8 +256,20 @@ func DeleteComment(c *gin.Context) {
 	}
 
 	// Delete comment and its replies
-	config.DB.Where("parent_id = ?", commentID).Delete(&models.Comment{})
-	config.DB.Delete(&comment)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		replies := tx.Where("parent_id = ?", commentID).Delete(&models.Comment{})
+		if replies.Error != nil {
+			return replies.Error
+		}
+		if err := tx.Delete(&comment).Error; err != nil {
+			return err
+		}
+		return counters.Comments(tx, comment.PostID, -int(replies.RowsAffected+1))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
 }
@@ -206,7 +295,7 @@ func GetUserComments(c *gin.Context) {
 	}
 
 	var posts []models.Post
-	if err := config.DB.Where("id IN ?", postIDs).
+	if err := visibleAuthors(config.DB.Where("id IN ? AND published = ?", postIDs, true), "author_id", userID.(uint)).
 		Preload("Author").
 		Order("created_at DESC").
 		Find(&posts).Error; err != nil {
@@ -234,9 +323,11 @@ func GetUserResponses(c *gin.Context) {
 		return
 	}
 
-	// Get comments on user's posts (excluding user's own comments)
+	// Get comments on user's posts (excluding user's own comments and blocked users)
 	var comments []models.Comment
-	if err := config.DB.Where("post_id IN ? AND user_id != ?", userPostIDs, userID).
+	query := config.DB.Where("post_id IN ? AND user_id != ?", userPostIDs, userID)
+	query = excludeUsers(query, "user_id", blockedUserIDs(userID.(uint)))
+	if err := query.
 		Preload("User").
 		Preload("Post").
 		Order("created_at DESC").
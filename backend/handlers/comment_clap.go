@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+	"gin-quickstart/realtime"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// viewerCommentClaps returns how many times the viewer clapped for each of
+// the given comments
+func viewerCommentClaps(viewer uint, comments []models.Comment) map[uint]int {
+	claps := make(map[uint]int)
+	if viewer == 0 || len(comments) == 0 {
+		return claps
+	}
+
+	var ids []uint
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+		for _, reply := range comment.Replies {
+			ids = append(ids, reply.ID)
+		}
+	}
+
+	var rows []models.CommentClap
+	config.DB.Where("user_id = ? AND comment_id IN ?", viewer, ids).Find(&rows)
+	for _, r := range rows {
+		claps[r.CommentID] = r.Count
+	}
+	return claps
+}
+
+// publishCommentClaps pushes a comment's new counts to viewers of its post
+func publishCommentClaps(comment *models.Comment) {
+	realtime.Publish(realtime.PostTopic(comment.PostID), "comment.claps", gin.H{
+		"comment_id":    comment.ID,
+		"clap_count":    comment.ClapCount,
+		"clapper_count": comment.ClapperCount,
+	})
+}
+
+// ClapComment claps for a comment. Each call adds claps (one by default, or
+// {"claps": n}) up to models.MaxClaps per user.
+func ClapComment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	claps, ok := clapsInput(c)
+	if !ok {
+		return
+	}
+
+	var comment models.Comment
+	if err := config.DB.First(&comment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	if _, ok := findViewablePost(c, uint64(comment.PostID)); !ok {
+		return
+	}
+
+	if hasBlocked(comment.UserID, uid) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot clap for this comment"})
+		return
+	}
+
+	var clap models.CommentClap
+	firstClap := false
+	added := 0
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Insert first so rapid repeat clicks can't both create the row; the
+		// loser of the race locks and adds to the winner's row
+		clap = models.CommentClap{UserID: uid, CommentID: comment.ID, Count: claps}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&clap)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND comment_id = ?", uid, comment.ID).
+			First(&clap).Error
+
+		switch {
+		case err != nil:
+			return err
+		case result.RowsAffected == 1:
+			firstClap, added = true, claps
+		default:
+			added = min(claps, models.MaxClaps-clap.Count)
+			if added == 0 {
+				return nil
+			}
+			if err := tx.Model(&clap).Update("count", clap.Count+added).Error; err != nil {
+				return err
+			}
+			clap.Count += added
+		}
+
+		counters := map[string]interface{}{"clap_count": gorm.Expr("clap_count + ?", added)}
+		if firstClap {
+			counters["clapper_count"] = gorm.Expr("clapper_count + 1")
+		}
+		if err := tx.Model(&models.Comment{}).Where("id = ?", comment.ID).UpdateColumns(counters).Error; err != nil {
+			return err
+		}
+		return tx.First(&comment, comment.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clap for comment"})
+		return
+	}
+
+	if added == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already clapped 50 times", "claps": clap.Count})
+		return
+	}
+
+	publishCommentClaps(&comment)
+	if firstClap {
+		notify(comment.UserID, uid, "comment_clap", &comment.PostID, &comment.ID)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"claps":         clap.Count,
+		"clap_count":    comment.ClapCount,
+		"clapper_count": comment.ClapperCount,
+	})
+}
+
+// UnclapComment takes back all of the user's claps for a comment
+func UnclapComment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var comment models.Comment
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var clap models.CommentClap
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND comment_id = ?", userID, c.Param("id")).
+			First(&clap).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&clap).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("id = ?", clap.CommentID).UpdateColumns(map[string]interface{}{
+			"clap_count":    gorm.Expr("clap_count - ?", clap.Count),
+			"clapper_count": gorm.Expr("clapper_count - 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().First(&comment, clap.CommentID).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Clap not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove claps"})
+		return
+	}
+
+	publishCommentClaps(&comment)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Claps removed",
+		"clap_count":    comment.ClapCount,
+		"clapper_count": comment.ClapperCount,
+	})
+}
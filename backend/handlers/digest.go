@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"gin-quickstart/config"
+	"gin-quickstart/digest"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDigestSettings returns the user's email digest preference
+func GetDigestSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"frequency":      user.DigestFrequency,
+		"last_digest_at": user.LastDigestAt,
+	})
+}
+
+// UpdateDigestSettings opts the user in or out of the daily/weekly digest
+func UpdateDigestSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var input struct {
+		Frequency string `json:"frequency" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Frequency is required"})
+		return
+	}
+
+	if !digest.IsValidFrequency(input.Frequency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Frequency must be off, daily or weekly"})
+		return
+	}
+
+	if err := config.DB.Model(&models.User{}).Where("id = ?", userID).
+		Update("digest_frequency", input.Frequency).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update digest settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"frequency": input.Frequency})
+}
+
+// ConfirmUnsubscribeDigest shows the page behind the email's unsubscribe
+// link. It changes nothing: link prefetchers and mail scanners follow GET
+// links, so only the confirmation form's POST unsubscribes.
+func ConfirmUnsubscribeDigest(c *gin.Context) {
+	token := c.Query("token")
+	_, err := digest.ParseUnsubscribeToken(token)
+
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusBadRequest
+	}
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	digest.UnsubscribePage(c.Writer, token, false, err != nil)
+}
+
+// UnsubscribeDigest turns off the digest using the signed link from the
+// email, from the confirmation form or a mail client's RFC 8058 one-click
+// List-Unsubscribe POST
+func UnsubscribeDigest(c *gin.Context) {
+	token := c.Query("token")
+	userID, err := digest.ParseUnsubscribeToken(token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unsubscribe link"})
+		return
+	}
+
+	if err := config.DB.Model(&models.User{}).Where("id = ?", userID).
+		Update("digest_frequency", digest.FrequencyOff).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubscribe"})
+		return
+	}
+
+	// The confirmation form is submitted by a browser; mail clients get JSON
+	if strings.Contains(c.GetHeader("Accept"), "text/html") {
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		digest.UnsubscribePage(c.Writer, token, true, false)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "You have been unsubscribed from email digests"})
+}
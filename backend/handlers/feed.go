@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/feed"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// forYouFollowedPool, forYouRecentPool and forYouTrendingPool cap the
+	// candidates loaded per source; ranking is quadratic in the pool size
+	forYouFollowedPool = 500
+	forYouRecentPool   = 500
+	forYouTrendingPool = 100
+
+	// trendingWindow is how far back likes and comments count as engagement
+	trendingWindow = 7 * 24 * time.Hour
+)
+
+// historyRow is one post the reader interacted with
+type historyRow struct {
+	PostID   uint
+	AuthorID uint
+	Tags     string
+}
+
+// readerProfile summarizes the reader's likes, bookmarks, comments and
+// finished reads into author and tag affinities, and returns the posts they
+// have already read. Only finished reads mark a post read; a bookmark is
+// often a post saved for later, and likes and comments can come from a skim.
+func readerProfile(userID uint, w feed.Weights) (feed.Profile, map[uint]bool) {
+	profile := feed.Profile{Authors: map[uint]float64{}, Tags: map[string]float64{}}
+	read := make(map[uint]bool)
+
+	sources := []struct {
+		table  string
+		filter string
+		weight float64
+		marks  bool // Posts from this source count as read
+	}{
+		{"likes", "likes.deleted_at IS NULL", w.Like, false},
+		{"bookmarks", "bookmarks.deleted_at IS NULL", w.Bookmark, false},
+		{"comments", "comments.deleted_at IS NULL", w.Comment, false},
+		{"reading_progresses", "reading_progresses.read = true AND reading_progresses.deleted_at IS NULL", w.Read, true},
+	}
+	for _, s := range sources {
+		var rows []historyRow
+		config.DB.Table(s.table).
+			Select("posts.id AS post_id, posts.author_id, posts.tags").
+			Joins("JOIN posts ON posts.id = "+s.table+".post_id AND posts.deleted_at IS NULL").
+			Where(s.table+".user_id = ? AND "+s.filter, userID).
+			Scan(&rows)
+
+		for _, r := range rows {
+			if s.marks {
+				read[r.PostID] = true
+			}
+			profile.Authors[r.AuthorID] += s.weight
+			post := models.Post{Tags: r.Tags}
+			for _, tag := range post.TagList() {
+				profile.Tags[tag] += s.weight
+			}
+		}
+	}
+
+	return profile, read
+}
+
+// engagementCounts returns recent likes plus comments for each post
+func engagementCounts(postIDs []uint, since time.Time) map[uint]int64 {
+	counts := make(map[uint]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts
+	}
+
+	for _, model := range []interface{}{&models.Like{}, &models.Comment{}} {
+		var rows []struct {
+			PostID uint
+			Count  int64
+		}
+		config.DB.Model(model).
+			Select("post_id, COUNT(*) AS count").
+			Where("post_id IN ? AND created_at > ?", postIDs, since).
+			Group("post_id").
+			Scan(&rows)
+		for _, r := range rows {
+			counts[r.PostID] += r.Count
+		}
+	}
+	return counts
+}
+
+// GetForYouFeed returns published posts ranked for the reader, blending
+// followed authors, followed topics and trending posts. Posts the reader has
+// already read are left out and authors are spread across the feed. The
+// ranking weights come from feed.LoadWeights.
+func GetForYouFeed(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	weights := feed.LoadWeights()
+	now := time.Now()
+
+	profile, read := readerProfile(uid, weights)
+
+	var followingIDs []uint
+	config.DB.Model(&models.Follow{}).Where("follower_id = ?", uid).Pluck("following_id", &followingIDs)
+	followed := make(map[uint]bool, len(followingIDs))
+	for _, id := range followingIDs {
+		followed[id] = true
+	}
+
+	var topics []models.Topic
+	config.DB.Joins("JOIN topic_follows ON topic_follows.topic_id = topics.id AND topic_follows.deleted_at IS NULL").
+		Where("topic_follows.user_id = ?", uid).
+		Find(&topics)
+
+	// Eligible posts: recent, listed, visible to the reader and not their own
+	eligible := func() *gorm.DB {
+		query := config.DB.Model(&models.Post{}).
+			Where("published = ? AND unlisted = ? AND published_at > ? AND author_id <> ?", true, false, now.Add(-weights.Window), uid)
+		query = excludeUsers(query, "author_id", mutedUserIDs(uid))
+		query = excludeUsers(query, "author_id", blockedUserIDs(uid))
+		return visibleAuthors(query, "author_id", uid)
+	}
+
+	pool := make(map[uint]models.Post)
+	var posts []models.Post
+
+	// Followed authors, however busy the rest of the site is
+	if len(followingIDs) > 0 {
+		eligible().Where("author_id IN ?", followingIDs).Order("published_at DESC").Limit(forYouFollowedPool).Find(&posts)
+		for _, p := range posts {
+			pool[p.ID] = p
+		}
+	}
+
+	// Recent posts, from which followed topics and history matches are drawn
+	posts = nil
+	eligible().Order("published_at DESC").Limit(forYouRecentPool).Find(&posts)
+	for _, p := range posts {
+		pool[p.ID] = p
+	}
+
+	// Trending posts by recent engagement
+	var trendingIDs []uint
+	config.DB.Model(&models.Like{}).
+		Select("post_id").
+		Where("created_at > ?", now.Add(-trendingWindow)).
+		Group("post_id").
+		Order("COUNT(*) DESC").
+		Limit(forYouTrendingPool).
+		Pluck("post_id", &trendingIDs)
+	if len(trendingIDs) > 0 {
+		posts = nil
+		eligible().Where("id IN ?", trendingIDs).Find(&posts)
+		for _, p := range posts {
+			pool[p.ID] = p
+		}
+	}
+
+	ids := make([]uint, 0, len(pool))
+	for id := range pool {
+		if !read[id] {
+			ids = append(ids, id)
+		}
+	}
+	engagement := engagementCounts(ids, now.Add(-trendingWindow))
+
+	candidates := make([]feed.Candidate, 0, len(ids))
+	for _, id := range ids {
+		p := pool[id]
+		publishedAt := p.CreatedAt
+		if p.PublishedAt != nil {
+			publishedAt = *p.PublishedAt
+		}
+
+		matches := 0
+		for i := range topics {
+			if topics[i].Matches(&p) {
+				matches++
+			}
+		}
+
+		candidates = append(candidates, feed.Candidate{
+			PostID:         p.ID,
+			AuthorID:       p.AuthorID,
+			Tags:           p.TagList(),
+			PublishedAt:    publishedAt,
+			FollowedAuthor: followed[p.AuthorID],
+			TopicMatches:   matches,
+			Engagement:     engagement[p.ID],
+		})
+	}
+
+	ranked := feed.Rank(candidates, profile, weights, now)
+	total := len(ranked)
+
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	ranked = ranked[offset:end]
+
+	// Load the page with authors, keeping the ranked order
+	pageIDs := make([]uint, len(ranked))
+	for i, r := range ranked {
+		pageIDs[i] = r.PostID
+	}
+	var pagePosts []models.Post
+	if len(pageIDs) > 0 {
+		if err := config.DB.Where("id IN ?", pageIDs).Preload("Author").Find(&pagePosts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+			return
+		}
+	}
+	byID := make(map[uint]models.Post, len(pagePosts))
+	for _, p := range pagePosts {
+		byID[p.ID] = p
+	}
+
+	type FeedPost struct {
+		models.Post
+		Reason string  `json:"reason"`
+		Score  float64 `json:"score"`
+	}
+
+	result := make([]FeedPost, 0, len(ranked))
+	for _, r := range ranked {
+		if p, ok := byID[r.PostID]; ok {
+			result = append(result, FeedPost{Post: p, Reason: r.Reason, Score: r.Score})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts": result,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
@@ -3,13 +3,39 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"gin-quickstart/config"
+	"gin-quickstart/counters"
 	"gin-quickstart/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// addFollow makes follower follow following and counts it. Unfollowing and
+// blocking soft-delete follows, and the unique index still covers those rows,
+// so a deleted follow is brought back instead of inserted again. It reports
+// whether a follow was added; an existing one is left as is.
+func addFollow(tx *gorm.DB, followerID, followingID uint) (bool, error) {
+	now := time.Now()
+	follow := models.Follow{FollowerID: followerID, FollowingID: followingID}
+	result := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "follower_id"}, {Name: "following_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"deleted_at": nil,
+			"created_at": now,
+			"updated_at": now,
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "follows.deleted_at IS NOT NULL"}}},
+	}).Create(&follow)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	return true, counters.Follow(tx, followerID, followingID, 1)
+}
+
 // FollowUser follows a user
 func FollowUser(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -33,22 +59,54 @@ func FollowUser(c *gin.Context) {
 		return
 	}
 
+	// Blocks in either direction prevent following
+	if isBlockedEitherWay(userID.(uint), userToFollow.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot follow this user"})
+		return
+	}
+
 	// Check if already following
-	var existingFollow models.Follow
-	if err := config.DB.Where("follower_id = ? AND following_id = ?", userID, userToFollow.ID).First(&existingFollow).Error; err == nil {
+	if isFollowing(userID.(uint), userToFollow.ID) {
 		c.JSON(http.StatusConflict, gin.H{"error": "Already following this user"})
 		return
 	}
 
-	follow := models.Follow{
-		FollowerID:  userID.(uint),
-		FollowingID: userToFollow.ID,
+	// Private accounts approve their followers first
+	if userToFollow.Private {
+		request := models.FollowRequest{
+			RequesterID: userID.(uint),
+			TargetID:    userToFollow.ID,
+		}
+		result := config.DB.Where("requester_id = ? AND target_id = ?", request.RequesterID, request.TargetID).FirstOrCreate(&request)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send follow request"})
+			return
+		}
+		if result.RowsAffected > 0 {
+			notify(userToFollow.ID, request.RequesterID, "follow_request", nil, nil)
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "Follow request sent", "requested": true})
+		return
 	}
 
-	if err := config.DB.Create(&follow).Error; err != nil {
+	// A follow that ended with an unfollow or a block is restored
+	var added bool
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		added, err = addFollow(tx, userID.(uint), userToFollow.ID)
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow user"})
 		return
 	}
+	if !added {
+		c.JSON(http.StatusConflict, gin.H{"error": "Already following this user"})
+		return
+	}
+
+	announceFollow(&models.User{ID: userID.(uint), Username: c.GetString("username")}, &userToFollow)
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Successfully followed user"})
 }
@@ -70,8 +128,23 @@ func UnfollowUser(c *gin.Context) {
 		return
 	}
 
-	result := config.DB.Where("follower_id = ? AND following_id = ?", userID, userToUnfollow.ID).Delete(&models.Follow{})
-	if result.RowsAffected == 0 {
+	var unfollowed int64
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("follower_id = ? AND following_id = ?", userID, userToUnfollow.ID).Delete(&models.Follow{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		unfollowed = result.RowsAffected
+		return counters.Follow(tx, userID.(uint), userToUnfollow.ID, -1)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow user"})
+		return
+	}
+
+	// Also withdraws a pending follow request
+	requests := config.DB.Where("requester_id = ? AND target_id = ?", userID, userToUnfollow.ID).Delete(&models.FollowRequest{})
+	if unfollowed == 0 && requests.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Not following this user"})
 		return
 	}
@@ -98,11 +171,13 @@ func CheckFollowing(c *gin.Context) {
 
 	var follow models.Follow
 	if err := config.DB.Where("follower_id = ? AND following_id = ?", userID, user.ID).First(&follow).Error; err != nil {
-		c.JSON(http.StatusOK, gin.H{"following": false})
+		var requested int64
+		config.DB.Model(&models.FollowRequest{}).Where("requester_id = ? AND target_id = ?", userID, user.ID).Count(&requested)
+		c.JSON(http.StatusOK, gin.H{"following": false, "requested": requested > 0})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"following": true})
+	c.JSON(http.StatusOK, gin.H{"following": true, "requested": false})
 }
 
 // GetFollowingFeed returns posts from users that the authenticated user follows
@@ -125,11 +200,14 @@ func GetFollowingFeed(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
-	// Get IDs of users being followed
+	// Get IDs of users and publications being followed
 	var followingIDs []uint
 	config.DB.Model(&models.Follow{}).Where("follower_id = ?", userID).Pluck("following_id", &followingIDs)
 
-	if len(followingIDs) == 0 {
+	var publicationIDs []uint
+	config.DB.Model(&models.PublicationFollow{}).Where("user_id = ?", userID).Pluck("publication_id", &publicationIDs)
+
+	if len(followingIDs) == 0 && len(publicationIDs) == 0 {
 		c.JSON(http.StatusOK, gin.H{
 			"posts": []models.Post{},
 			"total": 0,
@@ -142,7 +220,17 @@ func GetFollowingFeed(c *gin.Context) {
 	var posts []models.Post
 	var total int64
 
-	query := config.DB.Where("author_id IN ? AND published = ?", followingIDs, true).Preload("Author")
+	query := config.DB.Where("published = ?", true).Preload("Author").Preload("Publication")
+	switch {
+	case len(publicationIDs) == 0:
+		query = query.Where("author_id IN ?", followingIDs)
+	case len(followingIDs) == 0:
+		query = query.Where("publication_id IN ? AND unlisted = ?", publicationIDs, false)
+	default:
+		query = query.Where("author_id IN ? OR (publication_id IN ? AND unlisted = ?)", followingIDs, publicationIDs, false)
+	}
+	query = excludeUsers(query, "author_id", mutedUserIDs(userID.(uint)))
+	query = visibleAuthors(query, "author_id", userID.(uint))
 	query.Model(&models.Post{}).Count(&total)
 
 	if err := query.Order("published_at DESC").Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
@@ -158,109 +246,181 @@ func GetFollowingFeed(c *gin.Context) {
 	})
 }
 
-// GetSuggestedUsers returns users to follow (users not currently followed)
-func GetSuggestedUsers(c *gin.Context) {
+// GetFollowingWriters returns users that the authenticated user follows
+func GetFollowingWriters(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "3"))
-	if limit < 1 || limit > 10 {
-		limit = 3
-	}
-
-	// Get IDs of users already being followed
+	// Get IDs of users being followed
 	var followingIDs []uint
 	config.DB.Model(&models.Follow{}).Where("follower_id = ?", userID).Pluck("following_id", &followingIDs)
 
-	// Add current user to exclude list
-	excludeIDs := append(followingIDs, userID.(uint))
+	if len(followingIDs) == 0 {
+		c.JSON(http.StatusOK, gin.H{"users": []models.User{}, "total": 0})
+		return
+	}
 
 	var users []models.User
-	query := config.DB.Where("id NOT IN ?", excludeIDs)
-
-	if err := query.Limit(limit).Find(&users).Error; err != nil {
+	if err := config.DB.Where("id IN ?", followingIDs).Find(&users).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
 
-	// Build response with follower counts
-	type UserSuggestion struct {
-		ID            uint   `json:"id"`
-		Username      string `json:"username"`
-		FullName      string `json:"full_name"`
-		Bio           string `json:"bio"`
-		Avatar        string `json:"avatar"`
-		FollowerCount int64  `json:"follower_count"`
-	}
+	result := summarizeUsers(users)
+	c.JSON(http.StatusOK, gin.H{"users": result, "total": len(result)})
+}
 
-	var suggestions []UserSuggestion
-	for _, u := range users {
-		var followerCount int64
-		config.DB.Model(&models.Follow{}).Where("following_id = ?", u.ID).Count(&followerCount)
-
-		suggestions = append(suggestions, UserSuggestion{
-			ID:            u.ID,
-			Username:      u.Username,
-			FullName:      u.FullName,
-			Bio:           u.Bio,
-			Avatar:        u.Avatar,
-			FollowerCount: followerCount,
-		})
-	}
+// GetFollowers lists the users following the given user
+func GetFollowers(c *gin.Context) {
+	listFollowGraph(c, "following_id", "follower_id")
+}
 
-	c.JSON(http.StatusOK, gin.H{"users": suggestions})
+// GetFollowing lists the users the given user follows
+func GetFollowing(c *gin.Context) {
+	listFollowGraph(c, "follower_id", "following_id")
 }
 
-// GetFollowingWriters returns users that the authenticated user follows
-func GetFollowingWriters(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+// listFollowGraph pages through one side of a user's follow graph. matchColumn
+// holds the profile owner and listColumn the users to return. Each entry says
+// how it relates to the viewer.
+func listFollowGraph(c *gin.Context, matchColumn, listColumn string) {
+	var user models.User
+	if err := config.DB.Where("username = ?", c.Param("username")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	// Get IDs of users being followed
-	var followingIDs []uint
-	config.DB.Model(&models.Follow{}).Where("follower_id = ?", userID).Pluck("following_id", &followingIDs)
-
-	if len(followingIDs) == 0 {
-		c.JSON(http.StatusOK, gin.H{"users": []models.User{}, "total": 0})
+	viewer := viewerID(c)
+	if !canViewPostsBy(viewer, &user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This account is private"})
 		return
 	}
 
-	var users []models.User
-	if err := config.DB.Where("id IN ?", followingIDs).Find(&users).Error; err != nil {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.Follow{}).Where(matchColumn+" = ?", user.ID)
+	query = excludeUsers(query, listColumn, blockedUserIDs(viewer))
+
+	var total int64
+	query.Count(&total)
+
+	var ids []uint
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Pluck(listColumn, &ids).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
 
-	// Build response with follower counts
-	type UserWithStats struct {
-		ID            uint   `json:"id"`
-		Username      string `json:"username"`
-		FullName      string `json:"full_name"`
-		Bio           string `json:"bio"`
-		Avatar        string `json:"avatar"`
-		FollowerCount int64  `json:"follower_count"`
+	var users []models.User
+	if len(ids) > 0 {
+		if err := config.DB.Where("id IN ?", ids).Find(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+			return
+		}
 	}
 
-	var result []UserWithStats
+	// Keep the follow order from the page query
+	byID := make(map[uint]models.User, len(users))
 	for _, u := range users {
-		var followerCount int64
-		config.DB.Model(&models.Follow{}).Where("following_id = ?", u.ID).Count(&followerCount)
-
-		result = append(result, UserWithStats{
-			ID:            u.ID,
-			Username:      u.Username,
-			FullName:      u.FullName,
-			Bio:           u.Bio,
-			Avatar:        u.Avatar,
-			FollowerCount: followerCount,
+		byID[u.ID] = u
+	}
+	ordered := make([]models.User, 0, len(ids))
+	for _, id := range ids {
+		if u, ok := byID[id]; ok {
+			ordered = append(ordered, u)
+		}
+	}
+
+	summaries := summarizeUsers(ordered)
+	following, followers := viewerRelations(viewer, ids)
+
+	type FollowEntry struct {
+		UserSummary
+		FollowedByViewer bool `json:"followed_by_viewer"` // The viewer follows this user
+		FollowsViewer    bool `json:"follows_viewer"`     // This user follows the viewer
+		Mutual           bool `json:"mutual"`
+	}
+
+	entries := make([]FollowEntry, 0, len(summaries))
+	for _, s := range summaries {
+		entries = append(entries, FollowEntry{
+			UserSummary:      s,
+			FollowedByViewer: following[s.ID],
+			FollowsViewer:    followers[s.ID],
+			Mutual:           following[s.ID] && followers[s.ID],
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"users": result, "total": len(result)})
+	c.JSON(http.StatusOK, gin.H{
+		"users": entries,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// UserSummary is the public card shown in user lists
+type UserSummary struct {
+	ID             uint   `json:"id"`
+	Username       string `json:"username"`
+	FullName       string `json:"full_name"`
+	Bio            string `json:"bio"`
+	Avatar         string `json:"avatar"`
+	FollowerCount  int    `json:"follower_count"`
+	FollowingCount int    `json:"following_count"`
+	PostCount      int    `json:"post_count"`
+}
+
+// summarizeUsers builds user cards from the users' stored counters
+func summarizeUsers(users []models.User) []UserSummary {
+	summaries := make([]UserSummary, 0, len(users))
+	for _, u := range users {
+		summaries = append(summaries, UserSummary{
+			ID:             u.ID,
+			Username:       u.Username,
+			FullName:       u.FullName,
+			Bio:            u.Bio,
+			Avatar:         u.Avatar,
+			FollowerCount:  u.FollowerCount,
+			FollowingCount: u.FollowingCount,
+			PostCount:      u.PostCount,
+		})
+	}
+	return summaries
+}
+
+// viewerRelations reports which of the given users the viewer follows and
+// which follow the viewer
+func viewerRelations(viewer uint, userIDs []uint) (following, followers map[uint]bool) {
+	following = make(map[uint]bool)
+	followers = make(map[uint]bool)
+	if viewer == 0 || len(userIDs) == 0 {
+		return following, followers
+	}
+
+	var ids []uint
+	config.DB.Model(&models.Follow{}).Where("follower_id = ? AND following_id IN ?", viewer, userIDs).Pluck("following_id", &ids)
+	for _, id := range ids {
+		following[id] = true
+	}
+
+	ids = nil
+	config.DB.Model(&models.Follow{}).Where("following_id = ? AND follower_id IN ?", viewer, userIDs).Pluck("follower_id", &ids)
+	for _, id := range ids {
+		followers[id] = true
+	}
+
+	return following, followers
 }
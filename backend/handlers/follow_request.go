@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+	"gin-quickstart/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// isFollowing reports whether follower follows the given user
+func isFollowing(followerID, followingID uint) bool {
+	var count int64
+	config.DB.Model(&models.Follow{}).Where("follower_id = ? AND following_id = ?", followerID, followingID).Count(&count)
+	return count > 0
+}
+
+// canViewPostsBy reports whether the viewer may see the author's posts:
+// the author is public, is the viewer, or has approved the viewer as a follower
+func canViewPostsBy(viewer uint, author *models.User) bool {
+	if !author.Private || viewer == author.ID {
+		return true
+	}
+	return viewer != 0 && isFollowing(viewer, author.ID)
+}
+
+// visibleAuthors filters out rows whose column references a private account
+// the viewer is not an approved follower of
+func visibleAuthors(query *gorm.DB, column string, viewer uint) *gorm.DB {
+	private := config.DB.Model(&models.User{}).Select("id").Where("private = ?", true)
+	if viewer != 0 {
+		approved := config.DB.Model(&models.Follow{}).Select("following_id").Where("follower_id = ?", viewer)
+		private = private.Where("id <> ? AND id NOT IN (?)", viewer, approved)
+	}
+	return query.Where(column+" NOT IN (?)", private)
+}
+
+// findViewablePost loads a published post with its author, responding 404
+// unless the viewer may see the author's posts, as showPost does
+func findViewablePost(c *gin.Context, postID uint64) (*models.Post, bool) {
+	var post models.Post
+	if err := config.DB.Preload("Author").First(&post, postID).Error; err != nil ||
+		!post.Published || !canViewPostsBy(viewerID(c), &post.Author) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return nil, false
+	}
+	return &post, true
+}
+
+// approveFollowRequest turns a pending request into a follow
+func approveFollowRequest(tx *gorm.DB, request *models.FollowRequest) error {
+	if _, err := addFollow(tx, request.RequesterID, request.TargetID); err != nil {
+		return err
+	}
+	return tx.Delete(request).Error
+}
+
+// announceFollow sends the notification and webhook for a new follow
+func announceFollow(follower, following *models.User) {
+	notify(following.ID, follower.ID, "follow", nil, nil)
+	emitFollow(follower, following)
+}
+
+// emitFollow sends only the webhook for a new follow, for follows the
+// followed user approved themselves and needs no notification about
+func emitFollow(follower, following *models.User) {
+	webhooks.Emit(webhooks.EventUserFollowed, following.ID, gin.H{
+		"follower_id":  follower.ID,
+		"follower":     follower.Username,
+		"following_id": following.ID,
+		"following":    following.Username,
+	})
+}
+
+// GetFollowRequests lists pending requests to follow the authenticated user
+func GetFollowRequests(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	var requests []models.FollowRequest
+	var total int64
+
+	query := config.DB.Model(&models.FollowRequest{}).Where("target_id = ?", userID)
+	query.Count(&total)
+
+	if err := query.Preload("Requester").Order("created_at DESC").Limit(limit).Offset(offset).Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch follow requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"requests": requests,
+		"total":    total,
+		"page":     page,
+		"limit":    limit,
+	})
+}
+
+// findIncomingFollowRequest loads a request addressed to the current user
+func findIncomingFollowRequest(c *gin.Context) (*models.FollowRequest, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	var request models.FollowRequest
+	if err := config.DB.Preload("Requester").Preload("Target").
+		Where("id = ? AND target_id = ?", c.Param("id"), userID).
+		First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Follow request not found"})
+		return nil, false
+	}
+
+	return &request, true
+}
+
+// AcceptFollowRequest approves a pending follow request
+func AcceptFollowRequest(c *gin.Context) {
+	request, ok := findIncomingFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return approveFollowRequest(tx, request)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept follow request"})
+		return
+	}
+
+	notify(request.RequesterID, request.TargetID, "follow_accepted", nil, nil)
+	emitFollow(&request.Requester, &request.Target)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Follow request accepted"})
+}
+
+// RejectFollowRequest declines a pending follow request
+func RejectFollowRequest(c *gin.Context) {
+	request, ok := findIncomingFollowRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Delete(request).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject follow request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Follow request rejected"})
+}
+
+// UpdatePrivacy makes the authenticated user's account private or public.
+// Going public approves every pending follow request.
+func UpdatePrivacy(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Private *bool `json:"private" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "private is required"})
+		return
+	}
+
+	var approved []models.FollowRequest
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("private", *input.Private).Error; err != nil {
+			return err
+		}
+		if *input.Private {
+			return nil
+		}
+
+		if err := tx.Preload("Requester").Where("target_id = ?", user.ID).Find(&approved).Error; err != nil {
+			return err
+		}
+		for i := range approved {
+			if err := approveFollowRequest(tx, &approved[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update privacy"})
+		return
+	}
+
+	for i := range approved {
+		notify(approved[i].RequesterID, user.ID, "follow_accepted", nil, nil)
+		emitFollow(&approved[i].Requester, user)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"private": user.Private, "approved_requests": len(approved)})
+}
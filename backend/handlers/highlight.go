@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"gin-quickstart/anchor"
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// topHighlightMinReaders is how many readers must highlight a passage
+// before it is shown as the post's top highlight
+const topHighlightMinReaders = 2
+
+// maxPostHighlights caps how many highlights one request returns
+const maxPostHighlights = 500
+
+// TopHighlight is the passage of a post the most readers highlighted
+type TopHighlight struct {
+	Quote       string `json:"quote"`
+	StartOffset int    `json:"start_offset"`
+	EndOffset   int    `json:"end_offset"`
+	Readers     int    `json:"readers"`
+}
+
+// recordRevision saves the post's current content as a revision. previous
+// holds the content being replaced, which is stored too when that version
+// predates revision tracking.
+func recordRevision(tx *gorm.DB, post *models.Post, previous *models.PostRevision, editorID uint) error {
+	if previous != nil {
+		if err := tx.Where("post_id = ? AND version = ?", previous.PostID, previous.Version).
+			FirstOrCreate(previous).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Create(&models.PostRevision{
+		PostID:   post.ID,
+		Version:  post.Version,
+		Title:    post.Title,
+		Content:  post.Content,
+		EditorID: &editorID,
+	}).Error
+}
+
+// reanchorHighlights moves the post's highlights onto its current content,
+// in the transaction that saves it. Each highlight is found in the revision
+// it was made on and carried over from there. Highlights whose passage was
+// removed are marked orphaned and keep the version they were last found in.
+func reanchorHighlights(tx *gorm.DB, post *models.Post) error {
+	var highlights []models.Highlight
+	if err := tx.Where("post_id = ? AND post_version < ? AND orphaned = ?", post.ID, post.Version, false).
+		Find(&highlights).Error; err != nil {
+		return err
+	}
+	if len(highlights) == 0 {
+		return nil
+	}
+
+	versions := make([]int, 0, len(highlights))
+	for _, h := range highlights {
+		versions = append(versions, h.PostVersion)
+	}
+	var revisions []models.PostRevision
+	if err := tx.Where("post_id = ? AND version IN ?", post.ID, versions).Find(&revisions).Error; err != nil {
+		return err
+	}
+	contents := make(map[int]string, len(revisions))
+	for _, r := range revisions {
+		contents[r.Version] = r.Content
+	}
+
+	now := time.Now()
+	for i := range highlights {
+		h := &highlights[i]
+		h.UpdatedAt = now
+		sel := anchor.Selector{Quote: h.Quote, Prefix: h.Prefix, Suffix: h.Suffix, Start: h.StartOffset}
+
+		var ok bool
+		if old, found := contents[h.PostVersion]; found {
+			sel, ok = anchor.Reanchor(old, post.Content, sel)
+		} else {
+			sel, ok = anchor.Resolve(post.Content, sel)
+		}
+
+		if !ok {
+			h.Orphaned = true
+			continue
+		}
+		h.PostVersion = post.Version
+		h.Prefix = sel.Prefix
+		h.Suffix = sel.Suffix
+		h.StartOffset = sel.Start
+	}
+
+	// Write every highlight back in batched upserts rather than a query each
+	return tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"post_version", "prefix", "suffix", "start_offset", "orphaned", "updated_at"}),
+	}).CreateInBatches(&highlights, 500).Error
+}
+
+// topHighlight finds the passage most readers highlighted publicly, or nil
+// when no passage has enough readers
+func topHighlight(post *models.Post) *TopHighlight {
+	var highlights []models.Highlight
+	config.DB.Select("user_id, quote, start_offset").
+		Where("post_id = ? AND post_version = ? AND visibility = ? AND orphaned = ?",
+			post.ID, post.Version, models.HighlightPublic, false).
+		Find(&highlights)
+
+	spans := make([]anchor.Span, len(highlights))
+	for i, h := range highlights {
+		spans[i] = anchor.Span{
+			Start:  h.StartOffset,
+			End:    h.StartOffset + utf8.RuneCountInString(h.Quote),
+			UserID: h.UserID,
+		}
+	}
+
+	start, end, readers := anchor.Hottest(spans)
+	content := []rune(post.Content)
+	if readers < topHighlightMinReaders || end > len(content) {
+		return nil
+	}
+
+	return &TopHighlight{
+		Quote:       string(content[start:end]),
+		StartOffset: start,
+		EndOffset:   end,
+		Readers:     readers,
+	}
+}
+
+// CreateHighlight highlights a passage of a post. The client sends the quote
+// with whatever context and offset it has; the server resolves it against the
+// current content and stores a normalized selector.
+func CreateHighlight(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var post models.Post
+	if err := config.DB.Preload("Author").First(&post, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	if !canViewPostsBy(uid, &post.Author) || (!post.Published && postAccess(uid, &post) == "") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	if hasBlocked(post.AuthorID, uid) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot highlight this post"})
+		return
+	}
+
+	var input struct {
+		Quote      string `json:"quote" binding:"required"`
+		Prefix     string `json:"prefix"`
+		Suffix     string `json:"suffix"`
+		Offset     int    `json:"offset"`
+		Version    int    `json:"version"` // Post version the reader was looking at
+		Note       string `json:"note"`
+		Visibility string `json:"visibility"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quote is required"})
+		return
+	}
+
+	if input.Visibility == "" {
+		input.Visibility = models.HighlightPublic
+	}
+	if input.Visibility != models.HighlightPublic && input.Visibility != models.HighlightPrivate {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Visibility must be public or private"})
+		return
+	}
+
+	sel, ok := anchor.Resolve(post.Content, anchor.Selector{
+		Quote:  input.Quote,
+		Prefix: input.Prefix,
+		Suffix: input.Suffix,
+		Start:  input.Offset,
+	})
+	if !ok {
+		if input.Version != 0 && input.Version != post.Version {
+			c.JSON(http.StatusConflict, gin.H{"error": "The post has changed; reload it and try again", "version": post.Version})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quote not found in post"})
+		return
+	}
+
+	highlight := models.Highlight{
+		PostID:      post.ID,
+		UserID:      uid,
+		PostVersion: post.Version,
+		Quote:       sel.Quote,
+		Prefix:      sel.Prefix,
+		Suffix:      sel.Suffix,
+		StartOffset: sel.Start,
+		Note:        input.Note,
+		Visibility:  input.Visibility,
+	}
+	if err := config.DB.Create(&highlight).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create highlight"})
+		return
+	}
+
+	config.DB.Preload("User").First(&highlight, highlight.ID)
+
+	c.JSON(http.StatusCreated, gin.H{"highlight": highlight})
+}
+
+// GetPostHighlights returns a post's public highlights plus the viewer's own,
+// with the post's top highlight
+func GetPostHighlights(c *gin.Context) {
+	viewer := viewerID(c)
+
+	var post models.Post
+	if err := config.DB.Preload("Author").Where("slug = ?", c.Param("slug")).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	if !canViewPostsBy(viewer, &post.Author) || (!post.Published && postAccess(viewer, &post) == "") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	// Others' public highlights on the current content; all of the viewer's
+	// own, including orphaned ones so they can be cleaned up
+	query := config.DB.Preload("User").Where("post_id = ?", post.ID)
+	if viewer != 0 {
+		query = query.Where("user_id = ? OR (visibility = ? AND orphaned = ?)", viewer, models.HighlightPublic, false)
+	} else {
+		query = query.Where("visibility = ? AND orphaned = ?", models.HighlightPublic, false)
+	}
+	query = excludeUsers(query, "user_id", mutedUserIDs(viewer))
+	query = excludeUsers(query, "user_id", blockedUserIDs(viewer))
+	query = visibleAuthors(query, "user_id", viewer)
+
+	var highlights []models.Highlight
+	if err := query.Order("start_offset ASC, created_at ASC").Limit(maxPostHighlights).Find(&highlights).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch highlights"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"highlights":    highlights,
+		"top_highlight": topHighlight(&post),
+		"version":       post.Version,
+	})
+}
+
+// GetMyHighlights returns the authenticated user's highlights, newest first
+func GetMyHighlights(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	var highlights []models.Highlight
+	var total int64
+
+	query := config.DB.Model(&models.Highlight{}).Where("user_id = ?", userID)
+	query.Count(&total)
+
+	if err := query.Preload("Post").Order("created_at DESC").Limit(limit).Offset(offset).Find(&highlights).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch highlights"})
+		return
+	}
+
+	type MyHighlight struct {
+		models.Highlight
+		PostSlug  string `json:"post_slug"`
+		PostTitle string `json:"post_title"`
+	}
+
+	result := make([]MyHighlight, len(highlights))
+	for i, h := range highlights {
+		result[i] = MyHighlight{Highlight: h, PostSlug: h.Post.Slug, PostTitle: h.Post.Title}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"highlights": result,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// DeleteHighlight deletes one of the authenticated user's highlights
+func DeleteHighlight(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.Highlight{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete highlight"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Highlight not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Highlight deleted"})
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-quickstart/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS publishes the public keys that verify our tokens so other services
+// can check them without a shared secret
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": utils.JWKS()})
+}
@@ -6,17 +6,45 @@ import (
 
 	"gin-quickstart/config"
 	"gin-quickstart/models"
+	"gin-quickstart/realtime"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// AddLike adds a like to a post
+// clapsInput reads the optional {"claps": n} body of a clap request,
+// defaulting to a single clap
+func clapsInput(c *gin.Context) (int, bool) {
+	var input struct {
+		Claps int `json:"claps"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return 0, false
+		}
+	}
+
+	if input.Claps == 0 {
+		input.Claps = 1
+	}
+	if input.Claps < 1 || input.Claps > models.MaxClaps {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Claps must be between 1 and 50"})
+		return 0, false
+	}
+	return input.Claps, true
+}
+
+// AddLike claps for a post. Each call adds claps (one by default, or
+// {"claps": n}) up to models.MaxClaps per user.
 func AddLike(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
+	uid := userID.(uint)
 
 	postIDStr := c.Param("postId")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
@@ -25,48 +53,88 @@ func AddLike(c *gin.Context) {
 		return
 	}
 
-	// Check if post exists
-	var post models.Post
-	if err := config.DB.First(&post, postID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+	claps, ok := clapsInput(c)
+	if !ok {
 		return
 	}
 
-	// Check if already liked (including soft-deleted likes)
-	var existingLike models.Like
-	err = config.DB.Unscoped().Where("user_id = ? AND post_id = ?", userID, postID).First(&existingLike).Error
+	post, ok := findViewablePost(c, postID)
+	if !ok {
+		return
+	}
 
-	if err == nil {
-		// Like record exists
-		if existingLike.DeletedAt.Valid {
-			// Soft-deleted like exists, restore it
-			config.DB.Unscoped().Model(&existingLike).Update("deleted_at", nil)
-		} else {
-			// Active like exists
-			c.JSON(http.StatusConflict, gin.H{"error": "Post already liked"})
-			return
+	var like models.Like
+	firstClap := false
+	added := 0
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Insert first so rapid repeat clicks can't both create the row; the
+		// loser of the race locks and adds to the winner's row
+		like = models.Like{UserID: uid, PostID: post.ID, Count: claps}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&like)
+		if result.Error != nil {
+			return result.Error
 		}
-	} else {
-		// No like record exists, create new one
-		like := models.Like{
-			UserID: userID.(uint),
-			PostID: uint(postID),
+
+		// Including claps taken back earlier, which are soft-deleted
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND post_id = ?", uid, post.ID).
+			First(&like).Error
+
+		switch {
+		case err != nil:
+			return err
+		case result.RowsAffected == 1:
+			firstClap, added = true, claps
+		case like.DeletedAt.Valid:
+			if err := tx.Unscoped().Model(&like).Updates(map[string]interface{}{"deleted_at": nil, "count": claps}).Error; err != nil {
+				return err
+			}
+			like.Count = claps
+			firstClap, added = true, claps
+		default:
+			added = min(claps, models.MaxClaps-like.Count)
+			if added == 0 {
+				return nil
+			}
+			if err := tx.Model(&like).Update("count", like.Count+added).Error; err != nil {
+				return err
+			}
+			like.Count += added
 		}
 
-		if err := config.DB.Create(&like).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add like"})
-			return
+		counters := map[string]interface{}{"clap_count": gorm.Expr("clap_count + ?", added)}
+		if firstClap {
+			counters["like_count"] = gorm.Expr("like_count + 1")
 		}
+		if err := tx.Model(&models.Post{}).Where("id = ?", post.ID).UpdateColumns(counters).Error; err != nil {
+			return err
+		}
+		return tx.Select("id", "like_count", "clap_count").First(post, post.ID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add like"})
+		return
 	}
 
-	// Get updated like count
-	var likeCount int64
-	config.DB.Model(&models.Like{}).Where("post_id = ?", postID).Count(&likeCount)
+	if added == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already clapped 50 times", "claps": like.Count})
+		return
+	}
+
+	realtime.Publish(realtime.PostTopic(post.ID), "like.count", gin.H{"post_id": post.ID, "like_count": post.LikeCount, "clap_count": post.ClapCount})
+	if firstClap {
+		notify(post.AuthorID, uid, "like", &post.ID, nil)
+	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Post liked successfully", "like_count": likeCount})
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Post liked successfully",
+		"claps":      like.Count,
+		"like_count": post.LikeCount,
+		"clap_count": post.ClapCount,
+	})
 }
 
-// RemoveLike removes a like from a post
+// RemoveLike takes back all of the user's claps for a post
 func RemoveLike(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -81,20 +149,41 @@ func RemoveLike(c *gin.Context) {
 		return
 	}
 
-	result := config.DB.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.Like{})
-	if result.RowsAffected == 0 {
+	var post models.Post
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var like models.Like
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND post_id = ?", userID, postID).
+			First(&like).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&like).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Post{}).Where("id = ?", postID).UpdateColumns(map[string]interface{}{
+			"like_count": gorm.Expr("like_count - 1"),
+			"clap_count": gorm.Expr("clap_count - ?", like.Count),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Select("id", "like_count", "clap_count").First(&post, postID).Error
+	})
+	if err == gorm.ErrRecordNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Like not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove like"})
+		return
+	}
 
-	// Get updated like count
-	var likeCount int64
-	config.DB.Model(&models.Like{}).Where("post_id = ?", postID).Count(&likeCount)
+	realtime.Publish(realtime.PostTopic(post.ID), "like.count", gin.H{"post_id": post.ID, "like_count": post.LikeCount, "clap_count": post.ClapCount})
 
-	c.JSON(http.StatusOK, gin.H{"message": "Like removed successfully", "like_count": likeCount})
+	c.JSON(http.StatusOK, gin.H{"message": "Like removed successfully", "like_count": post.LikeCount, "clap_count": post.ClapCount})
 }
 
-// CheckLike checks if a post is liked by the user and returns the like count
+// CheckLike returns how many times the user clapped for a post, with the
+// post's counts
 func CheckLike(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -109,21 +198,28 @@ func CheckLike(c *gin.Context) {
 		return
 	}
 
-	// Get like count
-	var likeCount int64
-	config.DB.Model(&models.Like{}).Where("post_id = ?", postID).Count(&likeCount)
+	var post models.Post
+	if err := config.DB.Select("id", "like_count", "clap_count").First(&post, postID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
 
 	// Check if user has liked
 	var like models.Like
-	liked := false
+	claps := 0
 	if err := config.DB.Where("user_id = ? AND post_id = ?", userID, postID).First(&like).Error; err == nil {
-		liked = true
+		claps = like.Count
 	}
 
-	c.JSON(http.StatusOK, gin.H{"liked": liked, "like_count": likeCount})
+	c.JSON(http.StatusOK, gin.H{
+		"liked":      claps > 0,
+		"claps":      claps,
+		"like_count": post.LikeCount,
+		"clap_count": post.ClapCount,
+	})
 }
 
-// GetLikeCount returns the like count for a post (public endpoint)
+// GetLikeCount returns the like and clap counts for a post (public endpoint)
 func GetLikeCount(c *gin.Context) {
 	postIDStr := c.Param("postId")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
@@ -132,10 +228,13 @@ func GetLikeCount(c *gin.Context) {
 		return
 	}
 
-	var likeCount int64
-	config.DB.Model(&models.Like{}).Where("post_id = ?", postID).Count(&likeCount)
+	var post models.Post
+	if err := config.DB.Select("id", "like_count", "clap_count").First(&post, postID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"like_count": likeCount})
+	c.JSON(http.StatusOK, gin.H{"like_count": post.LikeCount, "clap_count": post.ClapCount})
 }
 
 // GetLikedPosts returns posts liked by the authenticated user
@@ -156,7 +255,7 @@ func GetLikedPosts(c *gin.Context) {
 	}
 
 	var posts []models.Post
-	if err := config.DB.Where("id IN ?", postIDs).
+	if err := visibleAuthors(config.DB.Where("id IN ? AND published = ?", postIDs, true), "author_id", userID.(uint)).
 		Preload("Author").
 		Order("created_at DESC").
 		Find(&posts).Error; err != nil {
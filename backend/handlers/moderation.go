@@ -0,0 +1,533 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/counters"
+	"gin-quickstart/models"
+	"gin-quickstart/revocation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Moderation actions recorded in models.ModerationLog
+const (
+	actionDismiss        = "dismiss"
+	actionHideContent    = "hide_content"
+	actionRestoreContent = "restore_content"
+	actionSuspendUser    = "suspend_user"
+	actionUnsuspendUser  = "unsuspend_user"
+)
+
+var (
+	errReportTargetMissing = errors.New("reported content not found")
+	errCannotSuspend       = errors.New("user cannot be suspended")
+	errContentNotHidden    = errors.New("content is not hidden")
+)
+
+// moderationInput is the optional body for moderation actions
+type moderationInput struct {
+	Note         string `json:"note"`
+	DurationDays int    `json:"duration_days"` // Suspensions only; 0 means indefinite
+}
+
+// GetReports returns the moderation queue with optional filters
+func GetReports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.Report{})
+	if status := c.DefaultQuery("status", models.ReportStatusOpen); status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if reason := c.Query("reason"); reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var reports []models.Report
+	if err := query.Preload("Reporter").
+		Order("created_at ASC").
+		Limit(limit).Offset(offset).
+		Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetReport returns a report with the reported content, even if already hidden
+func GetReport(c *gin.Context) {
+	var report models.Report
+	if err := config.DB.Preload("Reporter").First(&report, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+
+	var target interface{}
+	switch report.TargetType {
+	case models.ReportTargetPost:
+		var post models.Post
+		if err := config.DB.Unscoped().Preload("Author").First(&post, report.TargetID).Error; err == nil {
+			target = post
+		}
+	case models.ReportTargetComment:
+		var comment models.Comment
+		if err := config.DB.Unscoped().Preload("User").First(&comment, report.TargetID).Error; err == nil {
+			target = comment
+		}
+	case models.ReportTargetUser:
+		var user models.User
+		if err := config.DB.Unscoped().First(&user, report.TargetID).Error; err == nil {
+			target = user
+		}
+	}
+
+	// Other reports about the same content help judge severity
+	var related int64
+	config.DB.Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND id != ?", report.TargetType, report.TargetID, report.ID).
+		Count(&related)
+
+	c.JSON(http.StatusOK, gin.H{"report": report, "target": target, "related_reports": related})
+}
+
+// DismissReport closes a report without taking action
+func DismissReport(c *gin.Context) {
+	resolveReport(c, actionDismiss)
+}
+
+// HideReportedContent hides the reported post or comment
+func HideReportedContent(c *gin.Context) {
+	resolveReport(c, actionHideContent)
+}
+
+// RestoreReportedContent reverses hiding the reported post or comment
+func RestoreReportedContent(c *gin.Context) {
+	moderatorID := c.MustGet("user_id").(uint)
+
+	var input moderationInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	var report models.Report
+	if err := config.DB.First(&report, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+
+	// The latest hide of the content, whichever of its reports it closed
+	var hidden models.ModerationLog
+	if err := config.DB.Where("action = ? AND target_type = ? AND target_id = ?", actionHideContent, report.TargetType, report.TargetID).
+		Order("created_at DESC").First(&hidden).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Reported content has not been hidden"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := restoreContent(tx, &hidden); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.ModerationLog{
+			ModeratorID: moderatorID,
+			Action:      actionRestoreContent,
+			ReportID:    &report.ID,
+			TargetType:  report.TargetType,
+			TargetID:    report.TargetID,
+			Note:        input.Note,
+		}).Error
+	})
+	switch {
+	case errors.Is(err, errReportTargetMissing):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reported content not found"})
+		return
+	case errors.Is(err, errContentNotHidden):
+		c.JSON(http.StatusConflict, gin.H{"error": "Reported content is not hidden"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore content"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Content restored"})
+}
+
+// SuspendReportedUser suspends the user responsible for the reported content
+func SuspendReportedUser(c *gin.Context) {
+	resolveReport(c, actionSuspendUser)
+}
+
+// resolveReport applies a moderation action to an open report, logs it and
+// notifies the reporters. Hiding or suspending also closes every other open
+// report about the same content.
+func resolveReport(c *gin.Context, action string) {
+	moderatorID := c.MustGet("user_id").(uint)
+
+	var input moderationInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	if input.DurationDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Duration cannot be negative"})
+		return
+	}
+
+	var report models.Report
+	if err := config.DB.First(&report, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+
+	if report.Status != models.ReportStatusOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Report has already been resolved"})
+		return
+	}
+
+	if action == actionHideContent && report.TargetType == models.ReportTargetUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User profiles cannot be hidden; suspend the user instead"})
+		return
+	}
+
+	now := time.Now()
+	var resolved []models.Report
+	var suspendedID uint
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		status := models.ReportStatusActioned
+		targetType, targetID := report.TargetType, report.TargetID
+		var membership *models.SeriesPost
+
+		switch action {
+		case actionDismiss:
+			status = models.ReportStatusDismissed
+		case actionHideContent:
+			var err error
+			if membership, err = hideContent(tx, report.TargetType, report.TargetID, now); err != nil {
+				return err
+			}
+		case actionSuspendUser:
+			ownerID, found := reportTargetOwner(tx.Unscoped(), report.TargetType, report.TargetID)
+			if !found {
+				return errReportTargetMissing
+			}
+			if err := suspendUser(tx, moderatorID, ownerID, input, now); err != nil {
+				return err
+			}
+			targetType, targetID = models.ReportTargetUser, ownerID
+			suspendedID = ownerID
+		}
+
+		// Dismissals close only this report; actions close all open reports on the target
+		query := tx.Where("status = ?", models.ReportStatusOpen)
+		if action == actionDismiss {
+			query = query.Where("id = ?", report.ID)
+		} else {
+			query = query.Where("target_type = ? AND target_id = ?", report.TargetType, report.TargetID)
+		}
+		if err := query.Find(&resolved).Error; err != nil {
+			return err
+		}
+
+		ids := make([]uint, len(resolved))
+		for i, r := range resolved {
+			ids[i] = r.ID
+		}
+		if err := tx.Model(&models.Report{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":         status,
+			"resolved_by_id": moderatorID,
+			"resolved_at":    now,
+			"resolution":     action,
+		}).Error; err != nil {
+			return err
+		}
+
+		entry := models.ModerationLog{
+			ModeratorID: moderatorID,
+			Action:      action,
+			ReportID:    &report.ID,
+			TargetType:  targetType,
+			TargetID:    targetID,
+			Note:        input.Note,
+			CreatedAt:   now,
+		}
+		if membership != nil {
+			entry.SeriesID, entry.SeriesPosition = &membership.SeriesID, &membership.Position
+		}
+		return tx.Create(&entry).Error
+	})
+	switch {
+	case errors.Is(err, errReportTargetMissing):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reported content not found"})
+		return
+	case errors.Is(err, errCannotSuspend):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot suspend yourself or another admin"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve report"})
+		return
+	}
+	if action == actionSuspendUser {
+		revocation.ApplyUser(suspendedID, now)
+	}
+
+	// Tell each reporter the outcome without revealing the moderator
+	message := "Thanks for your report. We reviewed it and took action."
+	if action == actionDismiss {
+		message = "Thanks for your report. We reviewed it and found no violation of our rules."
+	}
+	for _, r := range resolved {
+		sendNotification(models.Notification{
+			UserID:  r.ReporterID,
+			Type:    "report_resolved",
+			Message: message,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report resolved", "action": action, "resolved_reports": len(resolved)})
+}
+
+// hideContent removes a post or comment (and its replies) from public view.
+// Hidden rows are soft-deleted at exactly now, the time of the logged action,
+// which tells restoreContent them apart from rows their authors deleted. A
+// hidden post leaves its series; the membership it had is returned.
+func hideContent(tx *gorm.DB, targetType string, targetID uint, now time.Time) (*models.SeriesPost, error) {
+	switch targetType {
+	case models.ReportTargetPost:
+		var post models.Post
+		if err := tx.First(&post, targetID).Error; err == gorm.ErrRecordNotFound {
+			return nil, nil // Already gone
+		} else if err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&post).UpdateColumn("deleted_at", now).Error; err != nil {
+			return nil, err
+		}
+		if err := counters.Posts(tx, post.AuthorID); err != nil {
+			return nil, err
+		}
+
+		var membership models.SeriesPost
+		if err := tx.Where("post_id = ?", post.ID).First(&membership).Error; err == gorm.ErrRecordNotFound {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return &membership, leaveSeries(tx, &membership)
+	case models.ReportTargetComment:
+		var comment models.Comment
+		if err := tx.First(&comment, targetID).Error; err == gorm.ErrRecordNotFound {
+			return nil, nil // Already gone
+		} else if err != nil {
+			return nil, err
+		}
+		hidden := tx.Model(&models.Comment{}).Where("id = ? OR parent_id = ?", targetID, targetID).UpdateColumn("deleted_at", now)
+		if hidden.Error != nil {
+			return nil, hidden.Error
+		}
+		return nil, counters.Comments(tx, comment.PostID, -int(hidden.RowsAffected))
+	}
+	return nil, errors.New("unsupported target type")
+}
+
+// restoreContent brings back what the logged hide action removed: the post,
+// at its old place in its series if the series still exists, or the comment
+// with the replies hidden along with it
+func restoreContent(tx *gorm.DB, hidden *models.ModerationLog) error {
+	switch hidden.TargetType {
+	case models.ReportTargetPost:
+		var post models.Post
+		if err := tx.Unscoped().First(&post, hidden.TargetID).Error; err == gorm.ErrRecordNotFound {
+			return errReportTargetMissing
+		} else if err != nil {
+			return err
+		}
+		if !post.DeletedAt.Valid || !post.DeletedAt.Time.Equal(hidden.CreatedAt) {
+			return errContentNotHidden
+		}
+		if err := tx.Unscoped().Model(&post).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := counters.Posts(tx, post.AuthorID); err != nil {
+			return err
+		}
+
+		if hidden.SeriesID == nil {
+			return nil
+		}
+		var series models.Series
+		if err := tx.Select("id").First(&series, *hidden.SeriesID).Error; err == gorm.ErrRecordNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return joinSeries(tx, series.ID, post.ID, hidden.SeriesPosition)
+	case models.ReportTargetComment:
+		var comment models.Comment
+		if err := tx.Unscoped().First(&comment, hidden.TargetID).Error; err == gorm.ErrRecordNotFound {
+			return errReportTargetMissing
+		} else if err != nil {
+			return err
+		}
+		if !comment.DeletedAt.Valid || !comment.DeletedAt.Time.Equal(hidden.CreatedAt) {
+			return errContentNotHidden
+		}
+		restored := tx.Unscoped().Model(&models.Comment{}).
+			Where("(id = ? OR parent_id = ?) AND deleted_at = ?", comment.ID, comment.ID, hidden.CreatedAt).
+			UpdateColumn("deleted_at", nil)
+		if restored.Error != nil {
+			return restored.Error
+		}
+		return counters.Comments(tx, comment.PostID, int(restored.RowsAffected))
+	}
+	return errors.New("unsupported target type")
+}
+
+// suspendUser marks a user suspended for input.DurationDays days, or
+// indefinitely. Moderators cannot suspend themselves or another admin.
+func suspendUser(tx *gorm.DB, moderatorID, userID uint, input moderationInput, now time.Time) error {
+	var target models.User
+	if err := tx.Unscoped().Select("id", "is_admin").First(&target, userID).Error; err == gorm.ErrRecordNotFound {
+		return errReportTargetMissing
+	} else if err != nil {
+		return err
+	}
+	if target.ID == moderatorID || target.IsAdmin {
+		return errCannotSuspend
+	}
+
+	var until *time.Time
+	if input.DurationDays > 0 {
+		t := now.AddDate(0, 0, input.DurationDays)
+		until = &t
+	}
+
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"suspended_at":      now,
+		"suspended_until":   until,
+		"suspension_reason": input.Note,
+	}).Error; err != nil {
+		return err
+	}
+
+	// End the user's sessions; login and refresh refuse new ones while
+	// suspended. The caller applies the revocation locally after commit.
+	return revocation.RevokeUser(tx, userID, now)
+}
+
+// UnsuspendUser lifts a suspension
+func UnsuspendUser(c *gin.Context) {
+	moderatorID := c.MustGet("user_id").(uint)
+
+	var input moderationInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"suspended_at":      nil,
+			"suspended_until":   nil,
+			"suspension_reason": "",
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.ModerationLog{
+			ModeratorID: moderatorID,
+			Action:      actionUnsuspendUser,
+			TargetType:  models.ReportTargetUser,
+			TargetID:    user.ID,
+			Note:        input.Note,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsuspend user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unsuspended"})
+}
+
+// GetModerationLog returns past moderation actions, newest first
+func GetModerationLog(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.ModerationLog{})
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if moderatorID := c.Query("moderator_id"); moderatorID != "" {
+		query = query.Where("moderator_id = ?", moderatorID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var entries []models.ModerationLog
+	if err := query.Preload("Moderator").
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
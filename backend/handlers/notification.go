@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+	"gin-quickstart/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notify tells userID about something actorID did
+func notify(userID, actorID uint, notificationType string, postID, commentID *uint) {
+	// Don't notify users about their own activity
+	if userID == actorID {
+		return
+	}
+
+	sendNotification(models.Notification{
+		UserID:    userID,
+		ActorID:   &actorID,
+		Type:      notificationType,
+		PostID:    postID,
+		CommentID: commentID,
+	})
+}
+
+// notificationEvent is the notification pushed to streams. It carries only
+// what a toast needs: realtime payloads go through pg_notify, which rejects
+// anything over 8000 bytes, so the post's content is left out.
+type notificationEvent struct {
+	ID        uint        `json:"id"`
+	Type      string      `json:"type"`
+	Message   string      `json:"message,omitempty"`
+	PostID    *uint       `json:"post_id"`
+	CommentID *uint       `json:"comment_id"`
+	Read      bool        `json:"read"`
+	CreatedAt time.Time   `json:"created_at"`
+	Actor     *eventActor `json:"actor,omitempty"`
+	Post      *eventPost  `json:"post,omitempty"`
+}
+
+type eventActor struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+type eventPost struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+}
+
+// sendNotification stores a notification and pushes it to the recipient's stream
+func sendNotification(notification models.Notification) {
+	if err := config.DB.Create(&notification).Error; err != nil {
+		log.Printf("Failed to create %s notification: %v", notification.Type, err)
+		return
+	}
+
+	event := notificationEvent{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Message:   notification.Message,
+		PostID:    notification.PostID,
+		CommentID: notification.CommentID,
+		Read:      notification.Read,
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.ActorID != nil {
+		event.Actor = &eventActor{}
+		config.DB.Model(&models.User{}).Select("id", "username").Where("id = ?", *notification.ActorID).Take(event.Actor)
+	}
+	if notification.PostID != nil {
+		event.Post = &eventPost{}
+		config.DB.Model(&models.Post{}).Select("id", "title", "slug").Where("id = ?", *notification.PostID).Take(event.Post)
+	}
+
+	realtime.Publish(realtime.UserTopic(notification.UserID), "notification", event)
+}
+
+// GetNotifications returns the authenticated user's notifications, newest first
+func GetNotifications(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	var notifications []models.Notification
+	var total, unread int64
+
+	query := config.DB.Where("user_id = ?", userID)
+	query.Model(&models.Notification{}).Count(&total)
+	config.DB.Model(&models.Notification{}).Where("user_id = ? AND read = ?", userID, false).Count(&unread)
+
+	if err := query.Preload("Actor").Preload("Post").
+		Order("created_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"total":         total,
+		"unread":        unread,
+		"page":          page,
+		"limit":         limit,
+	})
+}
+
+// MarkNotificationRead marks a single notification as read
+func MarkNotificationRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	result := config.DB.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Update("read", true)
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllNotificationsRead marks every notification of the user as read
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := config.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read = ?", userID, false).
+		Update("read", true).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read"})
+}
@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+	"gin-quickstart/oidc"
+	"gin-quickstart/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// oidcStateTTL is how long a user has to finish signing in at the provider
+const oidcStateTTL = 10 * time.Minute
+
+var usernameCleaner = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// appURL is the frontend base URL that OIDC callbacks redirect to
+func appURL() string {
+	if u := os.Getenv("APP_URL"); u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	return "http://localhost:5173"
+}
+
+// redirectToApp sends the browser back to the frontend with the result in
+// the URL fragment, which is never sent to servers or logged by proxies
+func redirectToApp(c *gin.Context, values url.Values) {
+	c.Redirect(http.StatusFound, appURL()+"/auth/callback#"+values.Encode())
+}
+
+// oidcBindingCookie holds the secret that ties a login attempt to the
+// browser that started it
+const oidcBindingCookie = "oidc_binding"
+
+// newOIDCState stores the state, nonce and PKCE verifier for one attempt. It
+// is not usable until bindOIDCState ties it to a browser.
+func newOIDCState(provider *oidc.Provider, linkUserID *uint) (*models.OIDCLoginState, error) {
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.RandomString(48)
+	if err != nil {
+		return nil, err
+	}
+
+	loginState := models.OIDCLoginState{
+		State:        state,
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}
+	if err := config.DB.Create(&loginState).Error; err != nil {
+		return nil, err
+	}
+
+	return &loginState, nil
+}
+
+// bindOIDCState ties an attempt to the current browser with a cookie the
+// callback must present, so a callback URL from someone else's attempt
+// cannot sign this browser in (login CSRF). Each attempt is bound once. It
+// returns the provider's authorization URL.
+func bindOIDCState(c *gin.Context, provider *oidc.Provider, loginState *models.OIDCLoginState) (string, error) {
+	secret, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	result := config.DB.Model(&models.OIDCLoginState{}).
+		Where("id = ? AND browser_hash = ?", loginState.ID, "").
+		Update("browser_hash", utils.HashToken(secret))
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected != 1 {
+		return "", errors.New("login state already bound")
+	}
+
+	// Scoped to the provider's callback path on the API host; Lax still
+	// sends it on the provider's top-level redirect back
+	callbackURL, err := url.Parse(provider.RedirectURL)
+	if err != nil {
+		return "", err
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcBindingCookie, secret, int(oidcStateTTL.Seconds()), path.Dir(callbackURL.Path),
+		"", callbackURL.Scheme == "https", true)
+
+	return provider.AuthURL(loginState.State, loginState.Nonce, loginState.CodeVerifier)
+}
+
+// GetOIDCProviders lists the identity providers users can sign in with
+func GetOIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": oidc.Names()})
+}
+
+// StartOIDCLogin redirects the browser to the provider's sign-in page
+func StartOIDCLogin(c *gin.Context) {
+	provider, ok := oidc.Get(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	loginState, err := newOIDCState(provider, nil)
+	if err != nil {
+		log.Printf("oidc: failed to start %s login: %v", provider.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start sign-in"})
+		return
+	}
+
+	authURL, err := bindOIDCState(c, provider, loginState)
+	if err != nil {
+		log.Printf("oidc: failed to start %s login: %v", provider.Name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider unavailable"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// StartOIDCLink returns a URL that links the provider identity to the
+// signed-in account. The URL points at BeginOIDCLink on the API host rather
+// than at the provider, so the browser that opens it gets the binding cookie.
+func StartOIDCLink(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	provider, ok := oidc.Get(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	id := userID.(uint)
+	loginState, err := newOIDCState(provider, &id)
+	if err != nil {
+		log.Printf("oidc: failed to start %s link: %v", provider.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start linking"})
+		return
+	}
+
+	beginURL := strings.TrimSuffix(provider.RedirectURL, "/callback") + "/begin?" +
+		url.Values{"state": {loginState.State}}.Encode()
+	c.JSON(http.StatusOK, gin.H{"authorization_url": beginURL})
+}
+
+// BeginOIDCLink binds a link attempt from StartOIDCLink to the browser that
+// opened it and redirects to the provider
+func BeginOIDCLink(c *gin.Context) {
+	provider, ok := oidc.Get(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	var loginState models.OIDCLoginState
+	if err := config.DB.Where("state = ? AND provider = ? AND link_user_id IS NOT NULL AND expires_at > ?",
+		c.Query("state"), provider.Name, time.Now()).First(&loginState).Error; err != nil {
+		redirectToApp(c, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	authURL, err := bindOIDCState(c, provider, &loginState)
+	if err != nil {
+		redirectToApp(c, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback completes the authorization code flow, then signs the user in
+// (or links the identity) and redirects back to the frontend
+func OIDCCallback(c *gin.Context) {
+	provider, ok := oidc.Get(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		redirectToApp(c, url.Values{"error": {providerErr}})
+		return
+	}
+
+	// State is single use: only the callback whose delete removes the row
+	// may go on, so concurrent callbacks can't both exchange the code
+	var loginState models.OIDCLoginState
+	result := config.DB.Where("state = ? AND provider = ?", c.Query("state"), provider.Name).First(&loginState)
+	if result.Error != nil {
+		redirectToApp(c, url.Values{"error": {"invalid_state"}})
+		return
+	}
+	result = config.DB.Where("id = ?", loginState.ID).Delete(&models.OIDCLoginState{})
+	if result.Error != nil || result.RowsAffected != 1 {
+		redirectToApp(c, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	// The attempt must have been started in this browser
+	binding, err := c.Cookie(oidcBindingCookie)
+	callbackURL, _ := url.Parse(provider.RedirectURL)
+	c.SetCookie(oidcBindingCookie, "", -1, path.Dir(callbackURL.Path), "", callbackURL.Scheme == "https", true)
+	if err != nil || loginState.BrowserHash == "" ||
+		subtle.ConstantTimeCompare([]byte(utils.HashToken(binding)), []byte(loginState.BrowserHash)) != 1 {
+		redirectToApp(c, url.Values{"error": {"invalid_state"}})
+		return
+	}
+
+	if loginState.ExpiresAt.Before(time.Now()) {
+		redirectToApp(c, url.Values{"error": {"expired_state"}})
+		return
+	}
+
+	claims, err := provider.Exchange(c.Query("code"), loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Printf("oidc: %s callback failed: %v", provider.Name, err)
+		redirectToApp(c, url.Values{"error": {"authentication_failed"}})
+		return
+	}
+
+	user, linked, err := resolveOIDCUser(provider.Name, claims, loginState.LinkUserID)
+	if err != nil {
+		redirectToApp(c, url.Values{"error": {err.Error()}})
+		return
+	}
+
+	// Linking from settings does not start a new session
+	if linked && loginState.LinkUserID != nil {
+		redirectToApp(c, url.Values{"linked": {provider.Name}})
+		return
+	}
+
+	if user.IsSuspended(time.Now()) {
+		redirectToApp(c, url.Values{"error": {"account_suspended"}})
+		return
+	}
+
+	// The provider replaces the password, not the second factor
+	if user.TwoFactorEnabled {
+		mfaToken, err := utils.GenerateMFAToken(user.ID)
+		if err != nil {
+			redirectToApp(c, url.Values{"error": {"server_error"}})
+			return
+		}
+		redirectToApp(c, url.Values{"mfa_required": {"true"}, "mfa_token": {mfaToken}})
+		return
+	}
+
+	accessToken, refreshToken, err := createTokenPair(*user)
+	if err != nil {
+		redirectToApp(c, url.Values{"error": {"server_error"}})
+		return
+	}
+
+	redirectToApp(c, url.Values{"access_token": {accessToken}, "refresh_token": {refreshToken}})
+}
+
+// resolveOIDCUser finds the local user for an external identity. In order:
+// an existing link, an explicit link request, an existing account with the
+// same verified email, or a brand new account. It reports whether a new
+// identity link was created.
+func resolveOIDCUser(providerName string, claims *oidc.IDTokenClaims, linkUserID *uint) (*models.User, bool, error) {
+	now := time.Now()
+
+	var identity models.UserIdentity
+	if err := config.DB.Where("provider = ? AND subject = ?", providerName, claims.Subject).
+		First(&identity).Error; err == nil {
+		if linkUserID != nil && *linkUserID != identity.UserID {
+			return nil, false, errors.New("identity_linked_to_other_account")
+		}
+
+		config.DB.Model(&identity).Update("last_login_at", now)
+
+		var user models.User
+		if err := config.DB.First(&user, identity.UserID).Error; err != nil {
+			return nil, false, errors.New("account_not_found")
+		}
+		return &user, false, nil
+	}
+
+	email := strings.ToLower(claims.Email)
+	var user models.User
+
+	switch {
+	case linkUserID != nil:
+		if err := config.DB.First(&user, *linkUserID).Error; err != nil {
+			return nil, false, errors.New("account_not_found")
+		}
+
+	case email != "" && config.DB.Where("email = ?", email).First(&user).Error == nil:
+		// Only trust the provider's claim to this address if it verified it;
+		// otherwise anyone could take over an account by asserting its email
+		if !bool(claims.EmailVerified) {
+			return nil, false, errors.New("account_exists_sign_in_to_link")
+		}
+
+	default:
+		if email == "" {
+			return nil, false, errors.New("email_required")
+		}
+		created, err := createOIDCUser(claims, email)
+		if err != nil {
+			log.Printf("oidc: failed to create user: %v", err)
+			return nil, false, errors.New("server_error")
+		}
+		user = *created
+	}
+
+	identity = models.UserIdentity{
+		UserID:      user.ID,
+		Provider:    providerName,
+		Subject:     claims.Subject,
+		Email:       email,
+		LastLoginAt: &now,
+	}
+	if err := config.DB.Create(&identity).Error; err != nil {
+		return nil, false, errors.New("server_error")
+	}
+
+	return &user, true, nil
+}
+
+// createOIDCUser registers a password-less account from ID token claims
+func createOIDCUser(claims *oidc.IDTokenClaims, email string) (*models.User, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(email, "@", 2)[0]
+	}
+	base = usernameCleaner.ReplaceAllString(base, "")
+	if len(base) < 3 {
+		base = "user"
+	}
+	if len(base) > 24 {
+		base = base[:24]
+	}
+
+	user := models.User{
+		Email:    email,
+		FullName: claims.Name,
+		Avatar:   claims.Picture,
+		Password: "", // No password: the account signs in through its identity provider
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Find a free username, adding a random suffix on collisions
+		username := base
+		for i := 0; i < 5; i++ {
+			var count int64
+			tx.Model(&models.User{}).Where("username = ?", username).Count(&count)
+			if count == 0 {
+				user.Username = username
+				return tx.Create(&user).Error
+			}
+
+			suffix, err := utils.GenerateRandomToken(3)
+			if err != nil {
+				return err
+			}
+			username = base + "-" + suffix
+		}
+		return errors.New("could not find a free username")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// GetIdentities lists the external identities linked to the user
+func GetIdentities(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var identities []models.UserIdentity
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch identities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": identities, "total": len(identities)})
+}
+
+// UnlinkIdentity removes a linked identity, unless it is the only way left to sign in
+func UnlinkIdentity(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var identity models.UserIdentity
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&identity).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Identity not found"})
+		return
+	}
+
+	var others int64
+	config.DB.Model(&models.UserIdentity{}).Where("user_id = ? AND id != ?", user.ID, identity.ID).Count(&others)
+	if user.Password == "" && others == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot remove your only sign-in method"})
+		return
+	}
+
+	if err := config.DB.Delete(&identity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink identity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Identity unlinked"})
+}
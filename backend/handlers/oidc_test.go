@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/keyring"
+	"gin-quickstart/models"
+	"gin-quickstart/oidc"
+	"gin-quickstart/oidc/oidctest"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupOIDC connects to TEST_DATABASE_URL and registers a provider backed by
+// a mock OIDC server. Tests are skipped without a database.
+func setupOIDC(t *testing.T) (*oidctest.Server, *oidc.Provider, *gin.Engine) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "test-secret-test-secret-test-secret")
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	config.DB = db
+	if err := db.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.UserIdentity{}, &models.OIDCLoginState{}, &models.SigningKey{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := keyring.Init(); err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+
+	server := oidctest.NewServer()
+	t.Cleanup(server.Close)
+
+	// Unique per test so runs don't collide on provider subjects or emails
+	suffix := strings.ToLower(strings.NewReplacer("/", "-", "_", "-").Replace(t.Name())) + "-" + time.Now().Format("150405.000000")
+	server.User = oidctest.Identity{
+		Subject:       "subject-" + suffix,
+		Email:         suffix + "@example.com",
+		EmailVerified: true,
+		Name:          "Test User",
+	}
+
+	provider := server.Provider("mock-"+suffix, "http://localhost/callback")
+	oidc.Register(provider)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/oidc/:provider/start", StartOIDCLogin)
+	router.GET("/oidc/:provider/begin", BeginOIDCLink)
+	router.GET("/oidc/:provider/callback", OIDCCallback)
+
+	return server, provider, router
+}
+
+// start begins an attempt as a browser does, through the start endpoint or,
+// when linking, the begin URL from StartOIDCLink. It returns the provider's
+// authorization URL and the binding cookie.
+func start(t *testing.T, router *gin.Engine, provider *oidc.Provider, linkUserID *uint) (string, *http.Cookie) {
+	t.Helper()
+
+	target := "/oidc/" + provider.Name + "/start"
+	if linkUserID != nil {
+		loginState, err := newOIDCState(provider, linkUserID)
+		if err != nil {
+			t.Fatalf("newOIDCState: %v", err)
+		}
+		target = "/oidc/" + provider.Name + "/begin?" + url.Values{"state": {loginState.State}}.Encode()
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("start status = %d, want 302", w.Code)
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == oidcBindingCookie {
+			return w.Header().Get("Location"), cookie
+		}
+	}
+	t.Fatal("start did not set the binding cookie")
+	return "", nil
+}
+
+// signIn runs a full authorization at the mock provider and returns the
+// callback query, the binding cookie and the fragment the frontend receives
+func signIn(t *testing.T, server *oidctest.Server, provider *oidc.Provider, router *gin.Engine, linkUserID *uint) (string, *http.Cookie, url.Values) {
+	t.Helper()
+
+	authURL, cookie := start(t, router, provider, linkUserID)
+	code, state, err := server.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+
+	query := url.Values{"code": {code}, "state": {state}}.Encode()
+	return query, cookie, callback(t, router, provider, query, cookie)
+}
+
+// callback calls the callback endpoint, from the browser holding cookie if
+// set, and parses the redirect fragment
+func callback(t *testing.T, router *gin.Engine, provider *oidc.Provider, query string, cookie *http.Cookie) url.Values {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/oidc/"+provider.Name+"/callback?"+query, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("callback status = %d, want 302", w.Code)
+	}
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parse redirect: %v", err)
+	}
+	values, err := url.ParseQuery(location.Fragment)
+	if err != nil {
+		t.Fatalf("parse fragment: %v", err)
+	}
+	return values
+}
+
+func createTestUser(t *testing.T, email string) models.User {
+	t.Helper()
+	user := models.User{Email: email, Username: strings.SplitN(email, "@", 2)[0], Password: "x"}
+	if err := config.DB.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func TestOIDCCallbackCreatesAccount(t *testing.T) {
+	server, provider, router := setupOIDC(t)
+
+	_, _, result := signIn(t, server, provider, router, nil)
+	if result.Get("access_token") == "" || result.Get("refresh_token") == "" {
+		t.Fatalf("expected tokens, got %v", result)
+	}
+
+	var identity models.UserIdentity
+	if err := config.DB.Where("provider = ? AND subject = ?", provider.Name, server.User.Subject).First(&identity).Error; err != nil {
+		t.Fatalf("identity not created: %v", err)
+	}
+	var user models.User
+	if err := config.DB.First(&user, identity.UserID).Error; err != nil || user.Email != server.User.Email {
+		t.Errorf("identity linked to unexpected user %+v (%v)", user, err)
+	}
+}
+
+func TestOIDCCallbackStateIsSingleUse(t *testing.T) {
+	server, provider, router := setupOIDC(t)
+
+	query, cookie, first := signIn(t, server, provider, router, nil)
+	if first.Get("error") != "" {
+		t.Fatalf("first callback failed: %v", first)
+	}
+
+	if replay := callback(t, router, provider, query, cookie); replay.Get("error") != "invalid_state" {
+		t.Errorf("replayed callback = %v, want invalid_state", replay)
+	}
+}
+
+func TestOIDCCallbackExpiredState(t *testing.T) {
+	server, provider, router := setupOIDC(t)
+
+	authURL, cookie := start(t, router, provider, nil)
+	code, state, err := server.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	config.DB.Model(&models.OIDCLoginState{}).Where("state = ?", state).Update("expires_at", time.Now().Add(-time.Minute))
+
+	result := callback(t, router, provider, url.Values{"code": {code}, "state": {state}}.Encode(), cookie)
+	if result.Get("error") != "expired_state" {
+		t.Errorf("callback = %v, want expired_state", result)
+	}
+
+	var remaining int64
+	config.DB.Model(&models.OIDCLoginState{}).Where("state = ?", state).Count(&remaining)
+	if remaining != 0 {
+		t.Error("expired state was not consumed")
+	}
+}
+
+func TestOIDCCallbackRequiresBindingCookie(t *testing.T) {
+	server, provider, router := setupOIDC(t)
+
+	// A callback URL from an attempt started in another browser
+	for name, cookie := range map[string]*http.Cookie{
+		"missing": nil,
+		"wrong":   {Name: oidcBindingCookie, Value: "not-the-binding"},
+	} {
+		authURL, _ := start(t, router, provider, nil)
+		code, state, err := server.Authorize(authURL)
+		if err != nil {
+			t.Fatalf("Authorize: %v", err)
+		}
+
+		result := callback(t, router, provider, url.Values{"code": {code}, "state": {state}}.Encode(), cookie)
+		if result.Get("error") != "invalid_state" || result.Get("access_token") != "" {
+			t.Errorf("%s cookie: callback = %v, want invalid_state", name, result)
+		}
+	}
+}
+
+func TestOIDCBeginBindsOnce(t *testing.T) {
+	_, provider, router := setupOIDC(t)
+
+	owner := createTestUser(t, "owner-"+strings.ToLower(t.Name())+"-"+time.Now().Format("150405.000000")+"@example.com")
+	loginState, err := newOIDCState(provider, &owner.ID)
+	if err != nil {
+		t.Fatalf("newOIDCState: %v", err)
+	}
+	target := "/oidc/" + provider.Name + "/begin?" + url.Values{"state": {loginState.State}}.Encode()
+
+	discovery, err := provider.Discover()
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, target, nil))
+	if first.Code != http.StatusFound || !strings.HasPrefix(first.Header().Get("Location"), discovery.AuthorizationEndpoint) {
+		t.Fatalf("first begin = %d %s, want redirect to the provider", first.Code, first.Header().Get("Location"))
+	}
+
+	// Opening the same link URL in a second browser must not rebind it
+	second := httptest.NewRecorder()
+	router.ServeHTTP(second, httptest.NewRequest(http.MethodGet, target, nil))
+	if strings.HasPrefix(second.Header().Get("Location"), discovery.AuthorizationEndpoint) {
+		t.Error("second begin redirected to the provider")
+	}
+}
+
+func TestOIDCCallbackLinksAccount(t *testing.T) {
+	server, provider, router := setupOIDC(t)
+
+	owner := createTestUser(t, "owner-"+server.User.Email)
+	_, _, result := signIn(t, server, provider, router, &owner.ID)
+	if result.Get("linked") != provider.Name {
+		t.Fatalf("link callback = %v, want linked=%s", result, provider.Name)
+	}
+
+	var identity models.UserIdentity
+	if err := config.DB.Where("provider = ? AND subject = ?", provider.Name, server.User.Subject).First(&identity).Error; err != nil {
+		t.Fatalf("identity not created: %v", err)
+	}
+	if identity.UserID != owner.ID {
+		t.Errorf("identity linked to user %d, want %d", identity.UserID, owner.ID)
+	}
+
+	// The same provider account can't be linked to a second user
+	other := createTestUser(t, "other-"+server.User.Email)
+	if _, _, result := signIn(t, server, provider, router, &other.ID); result.Get("error") != "identity_linked_to_other_account" {
+		t.Errorf("second link = %v, want identity_linked_to_other_account", result)
+	}
+
+	// Signing in with the linked identity reaches the owner
+	_, _, result = signIn(t, server, provider, router, nil)
+	if result.Get("access_token") == "" {
+		t.Errorf("sign-in with linked identity = %v, want tokens", result)
+	}
+}
+
+func TestOIDCCallbackRequiresVerifiedEmailToLink(t *testing.T) {
+	server, provider, router := setupOIDC(t)
+
+	createTestUser(t, server.User.Email)
+	server.User.EmailVerified = false
+
+	if _, _, result := signIn(t, server, provider, router, nil); result.Get("error") != "account_exists_sign_in_to_link" {
+		t.Errorf("callback = %v, want account_exists_sign_in_to_link", result)
+	}
+
+	var count int64
+	config.DB.Model(&models.UserIdentity{}).Where("provider = ? AND subject = ?", provider.Name, server.User.Subject).Count(&count)
+	if count != 0 {
+		t.Error("unverified email was linked to the existing account")
+	}
+}
@@ -8,9 +8,12 @@ import (
 	"time"
 
 	"gin-quickstart/config"
+	"gin-quickstart/counters"
 	"gin-quickstart/models"
+	"gin-quickstart/webhooks"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreatePost handles post creation
@@ -60,6 +63,7 @@ func CreatePost(c *gin.Context) {
 		Tags:       input.Tags,
 		Published:  input.Published,
 		ReadTime:   readTime,
+		Version:    1,
 	}
 
 	if input.Published {
@@ -67,7 +71,16 @@ func CreatePost(c *gin.Context) {
 		post.PublishedAt = &now
 	}
 
-	if err := config.DB.Create(&post).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		if err := counters.Posts(tx, post.AuthorID); err != nil {
+			return err
+		}
+		return recordRevision(tx, &post, nil, post.AuthorID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
@@ -75,6 +88,10 @@ func CreatePost(c *gin.Context) {
 	// Load author information
 	config.DB.Preload("Author").First(&post, post.ID)
 
+	if post.Published {
+		webhooks.Emit(webhooks.EventPostPublished, post.AuthorID, newWebhookPost(&post))
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"post": post})
 }
 
@@ -95,11 +112,14 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
-	// Check if user is the author
-	if post.AuthorID != userID.(uint) {
+	// Authors and co-authors edit a post; editors also edit posts submitted
+	// to their publication
+	access := postAccess(userID.(uint), &post)
+	if !canEditPost(access) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own posts"})
 		return
 	}
+	editor := canEditForPublication(userID.(uint), post.PublicationID)
 
 	var input struct {
 		Title      *string `json:"title"`
@@ -127,6 +147,13 @@ func UpdatePost(c *gin.Context) {
 		post.Slug = slug
 	}
 
+	// Content edits start a new version; highlights are re-anchored as it is saved
+	var previous *models.PostRevision
+	if input.Content != nil && *input.Content != post.Content {
+		previous = &models.PostRevision{PostID: post.ID, Version: post.Version, Title: post.Title, Content: post.Content}
+		post.Version++
+	}
+
 	if input.Content != nil {
 		post.Content = *input.Content
 		// Recalculate read time
@@ -146,18 +173,52 @@ func UpdatePost(c *gin.Context) {
 		post.Tags = *input.Tags
 	}
 
+	justPublished, justUnpublished := false, false
 	if input.Published != nil {
 		wasUnpublished := !post.Published
+
+		// Co-authors edit; going live or offline is the author's call
+		if *input.Published != post.Published && access == accessCoAuthor {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the author can publish or unpublish this post"})
+			return
+		}
+
+		// Posts submitted to a publication go live through its editors
+		if wasUnpublished && *input.Published && post.PublicationID != nil && !editor {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Posts in a publication are published by its editors"})
+			return
+		}
+
 		post.Published = *input.Published
+		justPublished = wasUnpublished && *input.Published
+		justUnpublished = !wasUnpublished && !*input.Published
 
 		// Set published_at if publishing for the first time
 		if wasUnpublished && *input.Published {
 			now := time.Now()
 			post.PublishedAt = &now
+			if post.PublicationID != nil {
+				post.SubmissionStatus = models.SubmissionPublished
+			}
 		}
 	}
 
-	if err := config.DB.Save(&post).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(models.PostCounters...).Save(&post).Error; err != nil {
+			return err
+		}
+		if err := counters.Posts(tx, post.AuthorID); err != nil {
+			return err
+		}
+		if previous != nil {
+			if err := recordRevision(tx, &post, previous, userID.(uint)); err != nil {
+				return err
+			}
+			return reanchorHighlights(tx, &post)
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
 		return
 	}
@@ -165,6 +226,17 @@ func UpdatePost(c *gin.Context) {
 	// Load author information
 	config.DB.Preload("Author").First(&post, post.ID)
 
+	// Drafts stay private; subscribers only hear that a live post went away
+	switch {
+	case justPublished:
+		webhooks.Emit(webhooks.EventPostPublished, post.AuthorID, newWebhookPost(&post))
+		announceSeriesPart(&post)
+	case justUnpublished:
+		webhooks.Emit(webhooks.EventPostUnpublished, post.AuthorID, gin.H{"id": post.ID, "slug": post.Slug, "title": post.Title})
+	case post.Published:
+		webhooks.Emit(webhooks.EventPostUpdated, post.AuthorID, newWebhookPost(&post))
+	}
+
 	c.JSON(http.StatusOK, gin.H{"post": post})
 }
 
@@ -191,11 +263,25 @@ func DeletePost(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Delete(&post).Error; err != nil {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&post).Error; err != nil {
+			return err
+		}
+		return counters.Posts(tx, post.AuthorID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
 		return
 	}
 
+	// Close the gap the post leaves in its series
+	var membership models.SeriesPost
+	if err := config.DB.Where("post_id = ?", post.ID).First(&membership).Error; err == nil {
+		leaveSeries(config.DB, &membership)
+	}
+
+	webhooks.Emit(webhooks.EventPostDeleted, post.AuthorID, gin.H{"id": post.ID, "slug": post.Slug, "title": post.Title})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Post deleted successfully"})
 }
 
@@ -204,16 +290,46 @@ func GetPost(c *gin.Context) {
 	slug := c.Param("slug")
 
 	var post models.Post
-	if err := config.DB.Preload("Author").Where("slug = ?", slug).First(&post).Error; err != nil {
+	if err := config.DB.Preload("Author").Preload("Publication").Where("slug = ?", slug).First(&post).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
 
-	// Increment view count
-	config.DB.Model(&post).Update("view_count", post.ViewCount+1)
-	post.ViewCount++
+	showPost(c, &post)
+}
 
-	c.JSON(http.StatusOK, gin.H{"post": post})
+// showPost responds with a single post for reading, counting the view
+func showPost(c *gin.Context, post *models.Post) {
+	if !canViewPost(viewerID(c), post) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	// Increment view count. Views by readers whose progress is tracked come
+	// from their first progress ping, the same event reads build on, so
+	// each reader is counted once and read ratios stay comparable.
+	if post.Published && !tracksReading(viewerID(c)) {
+		config.DB.Model(post).UpdateColumn("view_count", gorm.Expr("view_count + 1"))
+		post.ViewCount++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"post":          post,
+		"series":        seriesNavigation(post, viewerID(c)),
+		"top_highlight": topHighlight(post),
+		"progress":      readingProgress(viewerID(c), post.ID),
+	})
+}
+
+// canViewPost reports whether the viewer may read a post loaded with its
+// author. Posts by private accounts look missing to anyone not approved, and
+// drafts are only readable by the people working on them; everyone else
+// needs a preview link.
+func canViewPost(viewer uint, post *models.Post) bool {
+	if !canViewPostsBy(viewer, &post.Author) {
+		return false
+	}
+	return post.Published || postAccess(viewer, post) != ""
 }
 
 // GetPosts retrieves all published posts with pagination
@@ -234,8 +350,11 @@ func GetPosts(c *gin.Context) {
 	var posts []models.Post
 	var total int64
 
-	// Get only published posts
+	// Get only published posts, hiding authors the viewer has muted and
+	// private accounts the viewer doesn't follow
 	query := config.DB.Where("published = ?", true).Preload("Author")
+	query = excludeUsers(query, "author_id", mutedUserIDs(viewerID(c)))
+	query = visibleAuthors(query, "author_id", viewerID(c))
 
 	query.Model(&models.Post{}).Count(&total)
 
@@ -264,7 +383,7 @@ func GetPosts(c *gin.Context) {
 func GetStaffPicks(c *gin.Context) {
 	var posts []models.Post
 
-	if err := config.DB.Where("published = ?", true).
+	if err := visibleAuthors(config.DB.Where("published = ?", true), "author_id", 0).
 		Preload("Author").
 		Order("view_count DESC").
 		Limit(3).
@@ -281,6 +400,9 @@ func GetStaffPicks(c *gin.Context) {
 		AuthorName  string `json:"author_name"`
 		AuthorUsername string `json:"author_username"`
 		PublishedAt string `json:"published_at"`
+		LikeCount     int    `json:"like_count"`
+		CommentCount  int    `json:"comment_count"`
+		BookmarkCount int    `json:"bookmark_count"`
 	}
 
 	var picks []StaffPick
@@ -300,6 +422,9 @@ func GetStaffPicks(c *gin.Context) {
 			AuthorName:  authorName,
 			AuthorUsername: p.Author.Username,
 			PublishedAt: publishedAt,
+			LikeCount:     p.LikeCount,
+			CommentCount:  p.CommentCount,
+			BookmarkCount: p.BookmarkCount,
 		})
 	}
 
@@ -0,0 +1,781 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/counters"
+	"gin-quickstart/models"
+	"gin-quickstart/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PublicationMemberSummary is a member card on a publication's homepage
+type PublicationMemberSummary struct {
+	UserSummary
+	Role string `json:"role"`
+}
+
+// publicationMember returns the user's membership in a publication, or nil
+func publicationMember(publicationID, userID uint) *models.PublicationMember {
+	var member models.PublicationMember
+	if err := config.DB.Where("publication_id = ? AND user_id = ?", publicationID, userID).First(&member).Error; err != nil {
+		return nil
+	}
+	return &member
+}
+
+// canEditForPublication reports whether the user is an owner or editor of
+// the given publication
+func canEditForPublication(userID uint, publicationID *uint) bool {
+	if publicationID == nil {
+		return false
+	}
+	member := publicationMember(*publicationID, userID)
+	return member != nil && member.CanEdit()
+}
+
+// findPublication loads the publication named in the URL
+func findPublication(c *gin.Context) (*models.Publication, bool) {
+	var publication models.Publication
+	if err := config.DB.Where("slug = ?", c.Param("slug")).First(&publication).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Publication not found"})
+		return nil, false
+	}
+	return &publication, true
+}
+
+// requirePublicationRole loads the publication named in the URL and checks
+// the caller holds one of the given roles in it
+func requirePublicationRole(c *gin.Context, roles ...string) (*models.Publication, *models.PublicationMember, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, nil, false
+	}
+
+	publication, ok := findPublication(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	member := publicationMember(publication.ID, userID.(uint))
+	if member != nil {
+		for _, role := range roles {
+			if member.Role == role {
+				return publication, member, true
+			}
+		}
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to do that in this publication"})
+	return nil, nil, false
+}
+
+// notifyPublicationEditors tells a publication's owners and editors about a post
+func notifyPublicationEditors(publicationID, actorID uint, notificationType string, postID uint) {
+	var editorIDs []uint
+	config.DB.Model(&models.PublicationMember{}).
+		Where("publication_id = ? AND role IN ?", publicationID, []string{models.PublicationRoleOwner, models.PublicationRoleEditor}).
+		Pluck("user_id", &editorIDs)
+
+	for _, id := range editorIDs {
+		notify(id, actorID, notificationType, &postID, nil)
+	}
+}
+
+// findSubmission loads a post submitted to the publication
+func findSubmission(c *gin.Context, publication *models.Publication) (*models.Post, bool) {
+	var post models.Post
+	if err := config.DB.Where("id = ? AND publication_id = ?", c.Param("id"), publication.ID).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return nil, false
+	}
+	return &post, true
+}
+
+// CreatePublication creates a publication with the caller as its owner
+func CreatePublication(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var input struct {
+		Name        string `json:"name" binding:"required"`
+		Slug        string `json:"slug"`
+		Logo        string `json:"logo"`
+		Description string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+
+	slug := input.Slug
+	if slug == "" {
+		slug = input.Name
+	}
+	slug = generateSlug(slug)
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slug"})
+		return
+	}
+
+	var existing models.Publication
+	if err := config.DB.Unscoped().Where("slug = ?", slug).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Publication slug already taken"})
+		return
+	}
+
+	publication := models.Publication{
+		Name:        input.Name,
+		Slug:        slug,
+		Logo:        input.Logo,
+		Description: input.Description,
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&publication).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PublicationMember{
+			PublicationID: publication.ID,
+			UserID:        userID.(uint),
+			Role:          models.PublicationRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create publication"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"publication": publication})
+}
+
+// GetPublication returns a publication's homepage details: the publication,
+// its members and follower count
+func GetPublication(c *gin.Context) {
+	publication, ok := findPublication(c)
+	if !ok {
+		return
+	}
+	viewer := viewerID(c)
+
+	var members []models.PublicationMember
+	config.DB.Preload("User").Where("publication_id = ?", publication.ID).Order("created_at ASC").Find(&members)
+
+	users := make([]models.User, len(members))
+	for i, m := range members {
+		users[i] = m.User
+	}
+	summaries := summarizeUsers(users)
+
+	memberList := make([]PublicationMemberSummary, len(members))
+	viewerRole := ""
+	for i, m := range members {
+		memberList[i] = PublicationMemberSummary{UserSummary: summaries[i], Role: m.Role}
+		if m.UserID == viewer {
+			viewerRole = m.Role
+		}
+	}
+
+	var followerCount int64
+	config.DB.Model(&models.PublicationFollow{}).Where("publication_id = ?", publication.ID).Count(&followerCount)
+
+	following := false
+	if viewer != 0 {
+		var count int64
+		config.DB.Model(&models.PublicationFollow{}).Where("publication_id = ? AND user_id = ?", publication.ID, viewer).Count(&count)
+		following = count > 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"publication":    publication,
+		"members":        memberList,
+		"follower_count": followerCount,
+		"following":      following,
+		"viewer_role":    viewerRole,
+	})
+}
+
+// GetPublicationPosts returns a publication's published posts, newest first
+func GetPublicationPosts(c *gin.Context) {
+	publication, ok := findPublication(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	var posts []models.Post
+	var total int64
+
+	query := config.DB.Where("publication_id = ? AND published = ? AND unlisted = ?", publication.ID, true, false).Preload("Author")
+	query = excludeUsers(query, "author_id", mutedUserIDs(viewerID(c)))
+	query = visibleAuthors(query, "author_id", viewerID(c))
+
+	query.Model(&models.Post{}).Count(&total)
+
+	if err := query.Order("published_at DESC").Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"publication": publication,
+		"posts":       posts,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// GetPublicationPost returns a post by its slug under the publication's URL
+func GetPublicationPost(c *gin.Context) {
+	publication, ok := findPublication(c)
+	if !ok {
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.Preload("Author").Preload("Publication").
+		Where("slug = ? AND publication_id = ?", c.Param("postSlug"), publication.ID).
+		First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	showPost(c, &post)
+}
+
+// GetMyPublications lists the publications the authenticated user belongs to
+func GetMyPublications(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var memberships []models.PublicationMember
+	if err := config.DB.Preload("Publication").Where("user_id = ?", userID).Order("created_at ASC").Find(&memberships).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch publications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"memberships": memberships})
+}
+
+// UpdatePublication changes a publication's name, logo or description
+func UpdatePublication(c *gin.Context) {
+	publication, _, ok := requirePublicationRole(c, models.PublicationRoleOwner)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name        *string `json:"name"`
+		Logo        *string `json:"logo"`
+		Description *string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Name != nil {
+		if *input.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+			return
+		}
+		publication.Name = *input.Name
+	}
+	if input.Logo != nil {
+		publication.Logo = *input.Logo
+	}
+	if input.Description != nil {
+		publication.Description = *input.Description
+	}
+
+	if err := config.DB.Save(publication).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update publication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"publication": publication})
+}
+
+// DeletePublication deletes a publication. Its posts stay with their authors;
+// submissions still under review go back to being personal drafts.
+func DeletePublication(c *gin.Context) {
+	publication, _, ok := requirePublicationRole(c, models.PublicationRoleOwner)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Post{}).Where("publication_id = ?", publication.ID).
+			Updates(map[string]interface{}{"publication_id": nil, "submission_status": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("publication_id = ?", publication.ID).Delete(&models.PublicationMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("publication_id = ?", publication.ID).Delete(&models.PublicationFollow{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(publication).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete publication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Publication deleted successfully"})
+}
+
+// AddPublicationMember adds a user to the publication with a role
+func AddPublicationMember(c *gin.Context) {
+	publication, owner, ok := requirePublicationRole(c, models.PublicationRoleOwner)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Username string `json:"username" binding:"required"`
+		Role     string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username and role are required"})
+		return
+	}
+
+	if !models.IsValidPublicationRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be owner, editor or writer"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("username = ?", input.Username).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if isBlockedEitherWay(owner.UserID, user.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot add this user"})
+		return
+	}
+
+	if publicationMember(publication.ID, user.ID) != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member"})
+		return
+	}
+
+	member := models.PublicationMember{PublicationID: publication.ID, UserID: user.ID, Role: input.Role}
+	if err := config.DB.Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+
+	sendNotification(models.Notification{
+		UserID:  user.ID,
+		ActorID: &owner.UserID,
+		Type:    "publication_member",
+		Message: "You were added to " + publication.Name + " as " + input.Role,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{"member": member})
+}
+
+// findPublicationMember loads the member named in the URL
+func findPublicationMember(c *gin.Context, publication *models.Publication) (*models.PublicationMember, bool) {
+	var member models.PublicationMember
+	if err := config.DB.Joins("JOIN users ON users.id = publication_members.user_id").
+		Where("publication_members.publication_id = ? AND users.username = ?", publication.ID, c.Param("username")).
+		First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return nil, false
+	}
+	return &member, true
+}
+
+// isLastOwner reports whether the member is the publication's only owner
+func isLastOwner(member *models.PublicationMember) bool {
+	if member.Role != models.PublicationRoleOwner {
+		return false
+	}
+	var owners int64
+	config.DB.Model(&models.PublicationMember{}).
+		Where("publication_id = ? AND role = ?", member.PublicationID, models.PublicationRoleOwner).
+		Count(&owners)
+	return owners <= 1
+}
+
+// UpdatePublicationMember changes a member's role
+func UpdatePublicationMember(c *gin.Context) {
+	publication, _, ok := requirePublicationRole(c, models.PublicationRoleOwner)
+	if !ok {
+		return
+	}
+
+	member, ok := findPublicationMember(c, publication)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil || !models.IsValidPublicationRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be owner, editor or writer"})
+		return
+	}
+
+	if input.Role != models.PublicationRoleOwner && isLastOwner(member) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A publication needs at least one owner"})
+		return
+	}
+
+	if err := config.DB.Model(member).Update("role", input.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"member": member})
+}
+
+// RemovePublicationMember removes a member. Owners can remove anyone and
+// members can remove themselves. Their submissions still under review go
+// back to being personal drafts.
+func RemovePublicationMember(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	publication, ok := findPublication(c)
+	if !ok {
+		return
+	}
+
+	member, ok := findPublicationMember(c, publication)
+	if !ok {
+		return
+	}
+
+	if member.UserID != userID.(uint) {
+		caller := publicationMember(publication.ID, userID.(uint))
+		if caller == nil || caller.Role != models.PublicationRoleOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to do that in this publication"})
+			return
+		}
+	}
+
+	if isLastOwner(member) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A publication needs at least one owner"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Post{}).
+			Where("publication_id = ? AND author_id = ? AND published = ?", publication.ID, member.UserID, false).
+			Updates(map[string]interface{}{"publication_id": nil, "submission_status": ""}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(member).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// SubmitToPublication submits one of the caller's drafts for editorial review.
+// Rejected submissions can be resubmitted after editing.
+func SubmitToPublication(c *gin.Context) {
+	publication, member, ok := requirePublicationRole(c,
+		models.PublicationRoleOwner, models.PublicationRoleEditor, models.PublicationRoleWriter)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PostID uint `json:"post_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Post ID is required"})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.First(&post, input.PostID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	if post.AuthorID != member.UserID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only submit your own posts"})
+		return
+	}
+
+	if post.Published {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only drafts can be submitted"})
+		return
+	}
+
+	if post.PublicationID != nil && *post.PublicationID != publication.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Post is already submitted to another publication"})
+		return
+	}
+
+	if post.SubmissionStatus == models.SubmissionSubmitted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Post is already awaiting review"})
+		return
+	}
+
+	now := time.Now()
+	if err := config.DB.Model(&post).Updates(map[string]interface{}{
+		"publication_id":    publication.ID,
+		"submission_status": models.SubmissionSubmitted,
+		"submitted_at":      now,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit post"})
+		return
+	}
+
+	config.DB.First(&post, post.ID)
+	notifyPublicationEditors(publication.ID, member.UserID, "submission", post.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post submitted for review", "post": post})
+}
+
+// GetSubmissions lists posts submitted to a publication. Editors see every
+// submission; writers see their own.
+func GetSubmissions(c *gin.Context) {
+	publication, member, ok := requirePublicationRole(c,
+		models.PublicationRoleOwner, models.PublicationRoleEditor, models.PublicationRoleWriter)
+	if !ok {
+		return
+	}
+
+	status := c.DefaultQuery("status", models.SubmissionSubmitted)
+	if status != models.SubmissionSubmitted && status != models.SubmissionRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status must be submitted or rejected"})
+		return
+	}
+
+	query := config.DB.Preload("Author").Where("publication_id = ? AND submission_status = ?", publication.ID, status)
+	if !member.CanEdit() {
+		query = query.Where("author_id = ?", member.UserID)
+	}
+
+	var posts []models.Post
+	if err := query.Order("submitted_at ASC").Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch submissions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"submissions": posts})
+}
+
+// WithdrawSubmission takes a draft back out of a publication. Authors can
+// withdraw their own submissions; editors can drop any unpublished one.
+func WithdrawSubmission(c *gin.Context) {
+	publication, member, ok := requirePublicationRole(c,
+		models.PublicationRoleOwner, models.PublicationRoleEditor, models.PublicationRoleWriter)
+	if !ok {
+		return
+	}
+
+	post, ok := findSubmission(c, publication)
+	if !ok {
+		return
+	}
+
+	if post.AuthorID != member.UserID && !member.CanEdit() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only withdraw your own submissions"})
+		return
+	}
+
+	if post.Published {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Published posts cannot be withdrawn"})
+		return
+	}
+
+	if err := config.DB.Model(post).Updates(map[string]interface{}{
+		"publication_id":    nil,
+		"submission_status": "",
+		"submitted_at":      nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to withdraw submission"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Submission withdrawn"})
+}
+
+// PublishSubmission publishes a submitted post under the publication
+func PublishSubmission(c *gin.Context) {
+	publication, member, ok := requirePublicationRole(c, models.PublicationRoleOwner, models.PublicationRoleEditor)
+	if !ok {
+		return
+	}
+
+	post, ok := findSubmission(c, publication)
+	if !ok {
+		return
+	}
+
+	if post.Published || post.SubmissionStatus != models.SubmissionSubmitted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Post is not awaiting review"})
+		return
+	}
+
+	// Publish only if the post is still awaiting review, so a submission the
+	// author withdrew or another editor rejected meanwhile is left alone
+	now := time.Now()
+	published := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Post{}).
+			Where("id = ? AND submission_status = ? AND published = ?", post.ID, models.SubmissionSubmitted, false).
+			Updates(map[string]interface{}{
+				"published":         true,
+				"published_at":      now,
+				"scheduled_at":      nil,
+				"submission_status": models.SubmissionPublished,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		published = true
+		return counters.Posts(tx, post.AuthorID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish post"})
+		return
+	}
+	if !published {
+		c.JSON(http.StatusConflict, gin.H{"error": "Post is not awaiting review"})
+		return
+	}
+
+	config.DB.Preload("Author").Preload("Publication").First(post, post.ID)
+
+	webhooks.Emit(webhooks.EventPostPublished, post.AuthorID, newWebhookPost(post))
+	announceSeriesPart(post)
+	notify(post.AuthorID, member.UserID, "submission_published", &post.ID, nil)
+
+	c.JSON(http.StatusOK, gin.H{"post": post})
+}
+
+// RejectSubmission sends a submission back to its author with an optional note
+func RejectSubmission(c *gin.Context) {
+	publication, member, ok := requirePublicationRole(c, models.PublicationRoleOwner, models.PublicationRoleEditor)
+	if !ok {
+		return
+	}
+
+	post, ok := findSubmission(c, publication)
+	if !ok {
+		return
+	}
+
+	if post.SubmissionStatus != models.SubmissionSubmitted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Post is not awaiting review"})
+		return
+	}
+
+	var input struct {
+		Note string `json:"note"`
+	}
+	c.ShouldBindJSON(&input)
+
+	if err := config.DB.Model(post).Update("submission_status", models.SubmissionRejected).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject submission"})
+		return
+	}
+
+	if post.AuthorID != member.UserID {
+		sendNotification(models.Notification{
+			UserID:  post.AuthorID,
+			ActorID: &member.UserID,
+			Type:    "submission_rejected",
+			Message: input.Note,
+			PostID:  &post.ID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Submission rejected"})
+}
+
+// FollowPublication follows a publication so its posts show in the following feed
+func FollowPublication(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	publication, ok := findPublication(c)
+	if !ok {
+		return
+	}
+
+	var existing models.PublicationFollow
+	if err := config.DB.Where("user_id = ? AND publication_id = ?", userID, publication.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Already following this publication"})
+		return
+	}
+
+	if err := config.DB.Create(&models.PublicationFollow{UserID: userID.(uint), PublicationID: publication.ID}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow publication"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Successfully followed publication"})
+}
+
+// UnfollowPublication unfollows a publication
+func UnfollowPublication(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	publication, ok := findPublication(c)
+	if !ok {
+		return
+	}
+
+	result := config.DB.Where("user_id = ? AND publication_id = ?", userID, publication.ID).Delete(&models.PublicationFollow{})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not following this publication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully unfollowed publication"})
+}
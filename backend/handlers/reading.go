@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// readThreshold is the scroll percentage past which a post counts as read
+const readThreshold = 80.0
+
+// readingProgress returns the user's progress on a post, or nil
+func readingProgress(userID, postID uint) *models.ReadingProgress {
+	if userID == 0 {
+		return nil
+	}
+	var progress models.ReadingProgress
+	if err := config.DB.Where("user_id = ? AND post_id = ?", userID, postID).First(&progress).Error; err != nil {
+		return nil
+	}
+	return &progress
+}
+
+// tracksReading reports whether the viewer's views are counted by their
+// reading progress pings rather than when the post is fetched: they are
+// signed in and have not paused their history
+func tracksReading(viewer uint) bool {
+	if viewer == 0 {
+		return false
+	}
+	var user models.User
+	if err := config.DB.Select("id, history_paused").First(&user, viewer).Error; err != nil {
+		return false
+	}
+	return !user.HistoryPaused
+}
+
+// readRatio is the share of views that ended in a read
+func readRatio(views, reads int64) float64 {
+	if views == 0 {
+		return 0
+	}
+	ratio := float64(reads) / float64(views)
+	if ratio > 1 {
+		ratio = 1
+	}
+	return ratio
+}
+
+// UpdateReadingProgress records how far the authenticated user has scrolled
+// through a post. The client pings it while reading. A reader's first ping
+// counts as a view of the post, and the first time they pass readThreshold
+// the post is marked read and counted in its read count, so reads never
+// outnumber the views they came from.
+func UpdateReadingProgress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var input struct {
+		Percent  *float64 `json:"percent" binding:"required"`
+		Position int      `json:"position"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Percent is required"})
+		return
+	}
+
+	if *input.Percent < 0 || *input.Percent > 100 || input.Position < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Percent must be between 0 and 100"})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.Preload("Author").First(&post, c.Param("id")).Error; err != nil ||
+		!post.Published || !canViewPostsBy(uid, &post.Author) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Select("id, history_paused").First(&user, uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if user.HistoryPaused {
+		c.JSON(http.StatusOK, gin.H{"paused": true})
+		return
+	}
+
+	var progress models.ReadingProgress
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.ReadingProgress{UserID: uid, PostID: post.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			if err := tx.Model(&models.Post{}).Where("id = ?", post.ID).
+				UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		// Including posts removed from the history, which come back with
+		// their read flag so they aren't counted again
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND post_id = ?", uid, post.ID).
+			First(&progress).Error; err != nil {
+			return err
+		}
+		progress.DeletedAt = gorm.DeletedAt{}
+
+		progress.Percent = *input.Percent
+		progress.Position = input.Position
+		if progress.Percent > progress.MaxPercent {
+			progress.MaxPercent = progress.Percent
+		}
+
+		if !progress.Read && progress.MaxPercent >= readThreshold {
+			now := time.Now()
+			progress.Read = true
+			progress.ReadAt = &now
+			if err := tx.Model(&models.Post{}).Where("id = ?", post.ID).
+				UpdateColumn("read_count", gorm.Expr("read_count + 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Save(&progress).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reading progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"progress": progress})
+}
+
+// GetReadingHistory returns the posts the authenticated user has read,
+// most recently read first. ?read=true limits it to finished posts.
+func GetReadingHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.ReadingProgress{}).
+		Joins("JOIN posts ON posts.id = reading_progresses.post_id AND posts.deleted_at IS NULL").
+		Where("reading_progresses.user_id = ? AND posts.published = ?", uid, true)
+	if c.Query("read") == "true" {
+		query = query.Where("reading_progresses.read = ?", true)
+	}
+	query = visibleAuthors(query, "posts.author_id", uid)
+
+	var total int64
+	query.Count(&total)
+
+	var history []models.ReadingProgress
+	if err := query.Preload("Post").Preload("Post.Author").
+		Order("reading_progresses.updated_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading history"})
+		return
+	}
+
+	var user models.User
+	config.DB.Select("id, history_paused").First(&user, uid)
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": history,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+		"paused":  user.HistoryPaused,
+	})
+}
+
+// ClearReadingHistory deletes the authenticated user's whole reading history.
+// The rows are soft-deleted so rereading a post doesn't count it again.
+func ClearReadingHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := config.DB.Where("user_id = ?", userID).Delete(&models.ReadingProgress{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear reading history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reading history cleared"})
+}
+
+// RemoveReadingHistoryItem deletes one post from the authenticated user's
+// reading history
+func RemoveReadingHistoryItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result := config.DB.Where("user_id = ? AND post_id = ?", userID, c.Param("postId")).Delete(&models.ReadingProgress{})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found in reading history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post removed from reading history"})
+}
+
+// PauseReadingHistory turns recording of reading progress off or on
+func PauseReadingHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var input struct {
+		Paused *bool `json:"paused" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Paused is required"})
+		return
+	}
+
+	if err := config.DB.Model(&models.User{}).Where("id = ?", userID).Update("history_paused", *input.Paused).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reading history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"paused": *input.Paused})
+}
+
+// GetReadingStats returns view and read counts for the authenticated user's
+// published posts, with the estimated share of views that were read through
+func GetReadingStats(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.Post{}).Where("author_id = ? AND published = ?", userID, true)
+
+	var totals struct {
+		Posts int64
+		Views int64
+		Reads int64
+	}
+	query.Select("COUNT(*) AS posts, COALESCE(SUM(view_count), 0) AS views, COALESCE(SUM(read_count), 0) AS reads").Scan(&totals)
+
+	var posts []models.Post
+	if err := config.DB.Where("author_id = ? AND published = ?", userID, true).
+		Order("published_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading stats"})
+		return
+	}
+
+	ids := make([]uint, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	// How far signed-in readers got, on average
+	completion := make(map[uint]float64)
+	if len(ids) > 0 {
+		var rows []struct {
+			PostID  uint
+			Average float64
+		}
+		config.DB.Unscoped().Model(&models.ReadingProgress{}).
+			Select("post_id, AVG(max_percent) AS average").
+			Where("post_id IN ?", ids).
+			Group("post_id").
+			Scan(&rows)
+		for _, r := range rows {
+			completion[r.PostID] = r.Average
+		}
+	}
+
+	type PostStats struct {
+		PostID        uint       `json:"post_id"`
+		Slug          string     `json:"slug"`
+		Title         string     `json:"title"`
+		PublishedAt   *time.Time `json:"published_at"`
+		Views         int        `json:"views"`
+		Reads         int        `json:"reads"`
+		ReadRatio     float64    `json:"read_ratio"`
+		AvgCompletion float64    `json:"avg_completion"` // Mean furthest scroll percentage
+	}
+
+	stats := make([]PostStats, len(posts))
+	for i, p := range posts {
+		stats[i] = PostStats{
+			PostID:        p.ID,
+			Slug:          p.Slug,
+			Title:         p.Title,
+			PublishedAt:   p.PublishedAt,
+			Views:         p.ViewCount,
+			Reads:         p.ReadCount,
+			ReadRatio:     readRatio(int64(p.ViewCount), int64(p.ReadCount)),
+			AvgCompletion: completion[p.ID],
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":      stats,
+		"total":      totals.Posts,
+		"page":       page,
+		"limit":      limit,
+		"views":      totals.Views,
+		"reads":      totals.Reads,
+		"read_ratio": readRatio(totals.Views, totals.Reads),
+	})
+}
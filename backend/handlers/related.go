@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How many related posts the endpoint returns in each block
+const (
+	relatedLimit        = 5
+	moreFromAuthorLimit = 3
+)
+
+// GetRelatedPosts returns posts similar to the given one, one per author,
+// plus a "more from this writer" block. Both come from the set precomputed
+// by the related package; a post published since the last refresh has none yet.
+func GetRelatedPosts(c *gin.Context) {
+	slug := c.Param("slug")
+	viewer := viewerID(c)
+
+	var post models.Post
+	if err := config.DB.Preload("Author").Where("slug = ?", slug).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	if !canViewPostsBy(viewer, &post.Author) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	var rows []models.RelatedPost
+	config.DB.Where("post_id = ?", post.ID).Order("position ASC").Find(&rows)
+
+	ids := make([]uint, len(rows))
+	for i, r := range rows {
+		ids[i] = r.RelatedPostID
+	}
+
+	// Re-check the stored posts: they may have been unpublished or deleted
+	// since the refresh, and the viewer's mutes, blocks and follows apply
+	byID := make(map[uint]models.Post)
+	if len(ids) > 0 {
+		var posts []models.Post
+		query := config.DB.Preload("Author").Where("id IN ? AND published = ? AND unlisted = ?", ids, true, false)
+		query = excludeUsers(query, "author_id", mutedUserIDs(viewer))
+		query = excludeUsers(query, "author_id", blockedUserIDs(viewer))
+		query = visibleAuthors(query, "author_id", viewer)
+		if err := query.Find(&posts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch related posts"})
+			return
+		}
+		for _, p := range posts {
+			byID[p.ID] = p
+		}
+	}
+
+	related := []models.Post{}
+	moreFromAuthor := []models.Post{}
+	seenPosts := map[uint]bool{post.ID: true}
+	seenAuthors := map[uint]bool{post.AuthorID: true}
+
+	for _, r := range rows {
+		p, ok := byID[r.RelatedPostID]
+		if !ok || seenPosts[p.ID] {
+			continue
+		}
+
+		switch r.Kind {
+		case models.RelatedKindSimilar:
+			if len(related) == relatedLimit || seenAuthors[p.AuthorID] {
+				continue
+			}
+			seenAuthors[p.AuthorID] = true
+			related = append(related, p)
+		case models.RelatedKindAuthor:
+			if len(moreFromAuthor) == moreFromAuthorLimit || p.AuthorID != post.AuthorID {
+				continue
+			}
+			moreFromAuthor = append(moreFromAuthor, p)
+		default:
+			continue
+		}
+		seenPosts[p.ID] = true
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"related":          related,
+		"more_from_author": moreFromAuthor,
+	})
+}
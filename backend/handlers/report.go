@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// isValidReportReason reports whether reason is one of models.ReportReasons
+func isValidReportReason(reason string) bool {
+	for _, r := range models.ReportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// reportTargetOwner returns the user responsible for the reported content.
+// Pass config.DB.Unscoped() to include content that has already been hidden.
+func reportTargetOwner(db *gorm.DB, targetType string, targetID uint) (uint, bool) {
+	switch targetType {
+	case models.ReportTargetPost:
+		var post models.Post
+		if err := db.First(&post, targetID).Error; err != nil {
+			return 0, false
+		}
+		return post.AuthorID, true
+	case models.ReportTargetComment:
+		var comment models.Comment
+		if err := db.First(&comment, targetID).Error; err != nil {
+			return 0, false
+		}
+		return comment.UserID, true
+	case models.ReportTargetUser:
+		var user models.User
+		if err := db.First(&user, targetID).Error; err != nil {
+			return 0, false
+		}
+		return user.ID, true
+	}
+	return 0, false
+}
+
+// GetReportReasons lists the reason categories accepted by CreateReport
+func GetReportReasons(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"reasons": models.ReportReasons})
+}
+
+// CreateReport flags a post, comment or user profile for admin review
+func CreateReport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var input struct {
+		TargetType string `json:"target_type" binding:"required"`
+		TargetID   uint   `json:"target_id" binding:"required"`
+		Reason     string `json:"reason" binding:"required"`
+		Details    string `json:"details"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Target type, target ID and reason are required"})
+		return
+	}
+
+	if !isValidReportReason(input.Reason) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report reason"})
+		return
+	}
+
+	ownerID, found := reportTargetOwner(config.DB, input.TargetType, input.TargetID)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reported content not found"})
+		return
+	}
+
+	if ownerID == userID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot report your own content"})
+		return
+	}
+
+	// One open report per reporter and target
+	var existing models.Report
+	if err := config.DB.Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status = ?",
+		userID, input.TargetType, input.TargetID, models.ReportStatusOpen).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this"})
+		return
+	}
+
+	report := models.Report{
+		ReporterID: userID.(uint),
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+		Reason:     input.Reason,
+		Details:    input.Details,
+		Status:     models.ReportStatusOpen,
+	}
+
+	if err := config.DB.Create(&report).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create report"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Report submitted", "report": report})
+}
@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// seriesLink is a compact reference to a series or a post in one
+type seriesLink struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+}
+
+// SeriesNavigation places a post within its series
+type SeriesNavigation struct {
+	Series   seriesLink  `json:"series"`
+	Index    int         `json:"index"` // 1-based part number
+	Total    int         `json:"total"`
+	Previous *seriesLink `json:"previous"`
+	Next     *seriesLink `json:"next"`
+}
+
+// seriesParts returns the posts of a series in order. Drafts and scheduled
+// posts are only included for the series author.
+func seriesParts(seriesID, viewer uint) ([]models.Post, error) {
+	query := config.DB.Joins("JOIN series_posts ON series_posts.post_id = posts.id").
+		Where("series_posts.series_id = ?", seriesID).
+		Order("series_posts.position ASC")
+	query = query.Where("posts.published = ? OR posts.author_id = ?", true, viewer)
+
+	var posts []models.Post
+	err := query.Find(&posts).Error
+	return posts, err
+}
+
+// seriesNavigation returns the navigation for a post, or nil when the post
+// is not part of a series
+func seriesNavigation(post *models.Post, viewer uint) *SeriesNavigation {
+	var membership models.SeriesPost
+	if err := config.DB.Where("post_id = ?", post.ID).First(&membership).Error; err != nil {
+		return nil
+	}
+
+	var series models.Series
+	if err := config.DB.First(&series, membership.SeriesID).Error; err != nil {
+		return nil
+	}
+
+	parts, err := seriesParts(series.ID, viewer)
+	if err != nil {
+		return nil
+	}
+
+	nav := &SeriesNavigation{
+		Series: seriesLink{ID: series.ID, Title: series.Title, Slug: series.Slug},
+		Total:  len(parts),
+	}
+	for i, p := range parts {
+		if p.ID != post.ID {
+			continue
+		}
+		nav.Index = i + 1
+		if i > 0 {
+			nav.Previous = &seriesLink{ID: parts[i-1].ID, Title: parts[i-1].Title, Slug: parts[i-1].Slug}
+		}
+		if i+1 < len(parts) {
+			nav.Next = &seriesLink{ID: parts[i+1].ID, Title: parts[i+1].Title, Slug: parts[i+1].Slug}
+		}
+	}
+	return nav
+}
+
+// announceSeriesPart notifies a series' followers that a post in it has
+// been published. Each post is announced at most once.
+func announceSeriesPart(post *models.Post) {
+	if !post.Published || post.Unlisted {
+		return
+	}
+
+	var membership models.SeriesPost
+	if err := config.DB.Where("post_id = ?", post.ID).First(&membership).Error; err != nil {
+		return
+	}
+
+	var announced int64
+	config.DB.Model(&models.Notification{}).Where("type = ? AND post_id = ?", "series_part", post.ID).Count(&announced)
+	if announced > 0 {
+		return
+	}
+
+	var author models.User
+	if err := config.DB.First(&author, post.AuthorID).Error; err != nil {
+		return
+	}
+
+	var followerIDs []uint
+	config.DB.Model(&models.SeriesFollow{}).Where("series_id = ?", membership.SeriesID).Pluck("user_id", &followerIDs)
+	blocked := make(map[uint]bool)
+	for _, id := range blockedUserIDs(author.ID) {
+		blocked[id] = true
+	}
+
+	for _, followerID := range followerIDs {
+		if blocked[followerID] || !canViewPostsBy(followerID, &author) {
+			continue
+		}
+		notify(followerID, author.ID, "series_part", &post.ID, nil)
+	}
+}
+
+// joinSeries appends a post to a series, or inserts it at position and moves
+// later parts down
+func joinSeries(db *gorm.DB, seriesID, postID uint, position *int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.SeriesPost{}).Where("series_id = ?", seriesID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		at := int(count)
+		if position != nil && *position >= 0 && *position < at {
+			at = *position
+			if err := tx.Model(&models.SeriesPost{}).
+				Where("series_id = ? AND position >= ?", seriesID, at).
+				Update("position", gorm.Expr("position + 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&models.SeriesPost{SeriesID: seriesID, PostID: postID, Position: at}).Error
+	})
+}
+
+// leaveSeries deletes a post's membership and moves later parts up
+func leaveSeries(db *gorm.DB, membership *models.SeriesPost) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(membership).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.SeriesPost{}).
+			Where("series_id = ? AND position > ?", membership.SeriesID, membership.Position).
+			Update("position", gorm.Expr("position - 1")).Error
+	})
+}
+
+// findOwnSeries loads the series named in the URL and checks the caller owns it
+func findOwnSeries(c *gin.Context) (*models.Series, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	var series models.Series
+	if err := config.DB.First(&series, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return nil, false
+	}
+
+	if series.AuthorID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage your own series"})
+		return nil, false
+	}
+
+	return &series, true
+}
+
+// uniqueSeriesSlug derives a slug from the title that no other series uses
+func uniqueSeriesSlug(title string, excludeID uint) string {
+	slug := generateSlug(title)
+
+	var existing models.Series
+	if err := config.DB.Unscoped().Where("slug = ? AND id <> ?", slug, excludeID).First(&existing).Error; err == nil {
+		slug = slug + "-" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	return slug
+}
+
+// CreateSeries creates an empty series owned by the authenticated user
+func CreateSeries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var input struct {
+		Title       string `json:"title" binding:"required"`
+		Description string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required"})
+		return
+	}
+
+	series := models.Series{
+		AuthorID:    userID.(uint),
+		Title:       input.Title,
+		Slug:        uniqueSeriesSlug(input.Title, 0),
+		Description: input.Description,
+	}
+
+	if err := config.DB.Create(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create series"})
+		return
+	}
+
+	config.DB.Preload("Author").First(&series, series.ID)
+
+	c.JSON(http.StatusCreated, gin.H{"series": series})
+}
+
+// GetSeries returns a series with its posts in order
+func GetSeries(c *gin.Context) {
+	viewer := viewerID(c)
+
+	var series models.Series
+	if err := config.DB.Preload("Author").Where("slug = ?", c.Param("slug")).First(&series).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	// Series by private accounts look missing to anyone not approved
+	if !canViewPostsBy(viewer, &series.Author) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	posts, err := seriesParts(series.ID, viewer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series posts"})
+		return
+	}
+
+	var followerCount int64
+	config.DB.Model(&models.SeriesFollow{}).Where("series_id = ?", series.ID).Count(&followerCount)
+
+	following := false
+	if viewer != 0 {
+		var count int64
+		config.DB.Model(&models.SeriesFollow{}).Where("series_id = ? AND user_id = ?", series.ID, viewer).Count(&count)
+		following = count > 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":         series,
+		"posts":          posts,
+		"follower_count": followerCount,
+		"following":      following,
+	})
+}
+
+// GetUserSeries lists the series a user has created
+func GetUserSeries(c *gin.Context) {
+	var user models.User
+	if err := config.DB.Where("username = ?", c.Param("username")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !canViewPostsBy(viewerID(c), &user) {
+		c.JSON(http.StatusOK, gin.H{"series": []models.Series{}, "private": true})
+		return
+	}
+
+	var series []models.Series
+	if err := config.DB.Where("author_id = ?", user.ID).Order("created_at DESC").Find(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// UpdateSeries changes a series' title or description
+func UpdateSeries(c *gin.Context) {
+	series, ok := findOwnSeries(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Title       *string `json:"title"`
+		Description *string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Title != nil {
+		if *input.Title == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Title cannot be empty"})
+			return
+		}
+		series.Title = *input.Title
+		series.Slug = uniqueSeriesSlug(*input.Title, series.ID)
+	}
+
+	if input.Description != nil {
+		series.Description = *input.Description
+	}
+
+	if err := config.DB.Save(series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update series"})
+		return
+	}
+
+	config.DB.Preload("Author").First(series, series.ID)
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// DeleteSeries deletes a series. Its posts stay published on their own.
+func DeleteSeries(c *gin.Context) {
+	series, ok := findOwnSeries(c)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("series_id = ?", series.ID).Delete(&models.SeriesPost{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("series_id = ?", series.ID).Delete(&models.SeriesFollow{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(series).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series deleted successfully"})
+}
+
+// AddSeriesPost appends one of the author's posts to a series, or inserts it
+// at the given 0-based position
+func AddSeriesPost(c *gin.Context) {
+	series, ok := findOwnSeries(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PostID   uint `json:"post_id" binding:"required"`
+		Position *int `json:"position"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Post ID is required"})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.First(&post, input.PostID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	if post.AuthorID != series.AuthorID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only add your own posts"})
+		return
+	}
+
+	var existing models.SeriesPost
+	if err := config.DB.Where("post_id = ?", post.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Post is already part of a series"})
+		return
+	}
+
+	if err := joinSeries(config.DB, series.ID, post.ID, input.Position); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add post to series"})
+		return
+	}
+
+	announceSeriesPart(&post)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Post added to series"})
+}
+
+// RemoveSeriesPost takes a post out of a series and closes the gap it leaves
+func RemoveSeriesPost(c *gin.Context) {
+	series, ok := findOwnSeries(c)
+	if !ok {
+		return
+	}
+
+	var membership models.SeriesPost
+	if err := config.DB.Where("series_id = ? AND post_id = ?", series.ID, c.Param("postId")).First(&membership).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post is not part of this series"})
+		return
+	}
+
+	if err := leaveSeries(config.DB, &membership); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove post from series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post removed from series"})
+}
+
+// ReorderSeries sets the order of a series' posts. The request must list
+// every post in the series exactly once.
+func ReorderSeries(c *gin.Context) {
+	series, ok := findOwnSeries(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PostIDs []uint `json:"post_ids" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Post IDs are required"})
+		return
+	}
+
+	var memberships []models.SeriesPost
+	config.DB.Where("series_id = ?", series.ID).Find(&memberships)
+
+	current := make(map[uint]bool, len(memberships))
+	for _, m := range memberships {
+		current[m.PostID] = true
+	}
+
+	listed := make(map[uint]bool, len(input.PostIDs))
+	for _, id := range input.PostIDs {
+		if !current[id] || listed[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Post IDs must list every post in the series exactly once"})
+			return
+		}
+		listed[id] = true
+	}
+	if len(listed) != len(current) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Post IDs must list every post in the series exactly once"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range input.PostIDs {
+			if err := tx.Model(&models.SeriesPost{}).
+				Where("series_id = ? AND post_id = ?", series.ID, id).
+				Update("position", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series reordered"})
+}
+
+// FollowSeries subscribes the authenticated user to new parts of a series
+func FollowSeries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	uid := userID.(uint)
+
+	var series models.Series
+	if err := config.DB.Preload("Author").First(&series, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	if !canViewPostsBy(uid, &series.Author) || isBlockedEitherWay(uid, series.AuthorID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+		return
+	}
+
+	var existing models.SeriesFollow
+	if err := config.DB.Where("user_id = ? AND series_id = ?", uid, series.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Already following this series"})
+		return
+	}
+
+	if err := config.DB.Create(&models.SeriesFollow{UserID: uid, SeriesID: series.ID}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow series"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Successfully followed series"})
+}
+
+// UnfollowSeries stops notifications about a series
+func UnfollowSeries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result := config.DB.Where("user_id = ? AND series_id = ?", userID, c.Param("id")).Delete(&models.SeriesFollow{})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not following this series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully unfollowed series"})
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/middleware"
+	"gin-quickstart/models"
+	"gin-quickstart/realtime"
+	"gin-quickstart/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeat keeps idle connections open through proxies
+const streamHeartbeat = 25 * time.Second
+
+// streamRecheck is how often an open stream checks that the viewer may still
+// receive it
+const streamRecheck = time.Minute
+
+// streamTicketLifetime is how long a stream ticket may wait to be redeemed
+const streamTicketLifetime = 30 * time.Second
+
+// CreateStreamTicket issues a single-use ticket for opening the stream with
+// ?ticket=, for clients such as EventSource that cannot send headers. The
+// stream keeps checking the credential the ticket was issued for.
+func CreateStreamTicket(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	raw, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stream ticket"})
+		return
+	}
+
+	ticket := models.StreamTicket{
+		TicketHash:    utils.HashToken(raw),
+		UserID:        userID.(uint),
+		Email:         c.GetString("email"),
+		Username:      c.GetString("username"),
+		TokenID:       c.GetString("token_id"),
+		TokenIssuedAt: c.GetTime("token_issued_at"),
+		ExpiresAt:     time.Now().Add(streamTicketLifetime),
+	}
+	if id, ok := c.Get("personal_token_id"); ok {
+		personalTokenID := id.(uint)
+		ticket.PersonalTokenID = &personalTokenID
+		ticket.Scopes = strings.Join(c.GetStringSlice("token_scopes"), ",")
+	}
+	if expiresAt, ok := c.Get("token_expires_at"); ok {
+		t := expiresAt.(time.Time)
+		ticket.TokenExpiresAt = &t
+	}
+
+	if err := config.DB.Create(&ticket).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stream ticket"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"ticket":     raw,
+		"expires_at": ticket.ExpiresAt,
+	})
+}
+
+// canStreamPost reports whether the viewer may follow a post's activity
+func canStreamPost(viewer uint, postID uint64) bool {
+	var post models.Post
+	if err := config.DB.Preload("Author").First(&post, postID).Error; err != nil {
+		return false
+	}
+	return canViewPost(viewer, &post)
+}
+
+// Stream pushes live updates over Server-Sent Events. It always carries the
+// user's notifications; ?post_id= adds comments and like counts for that post.
+func Stream(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	topics := []string{realtime.UserTopic(userID.(uint))}
+	var watchedPost uint64
+	if postIDStr := c.Query("post_id"); postIDStr != "" {
+		postID, err := strconv.ParseUint(postIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+			return
+		}
+
+		// Only viewers who could open the post may follow its activity
+		if !canStreamPost(userID.(uint), postID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		watchedPost = postID
+		topics = append(topics, realtime.PostTopic(uint(postID)))
+	}
+
+	// Merge every subscribed topic into one channel
+	events := make(chan realtime.Event)
+	done := make(chan struct{})
+	defer close(done)
+
+	for _, topic := range topics {
+		ch, cancel := realtime.Bus.Subscribe(topic)
+		defer cancel()
+
+		go func(ch <-chan realtime.Event) {
+			for event := range ch {
+				select {
+				case events <- event:
+				case <-done:
+					return
+				}
+			}
+		}(ch)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	// Access is re-checked while the stream is open, so revoking or
+	// suspending the user, or removing them as a follower of a private
+	// author, ends it; the client reconnects with a fresh credential
+	recheck := time.NewTicker(streamRecheck)
+	defer recheck.Stop()
+
+	c.SSEvent("ready", gin.H{"topics": topics})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-events:
+			c.SSEvent(event.Type, event.Data)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case now := <-recheck.C:
+			if !middleware.CredentialValid(c, now) {
+				c.SSEvent("close", gin.H{"reason": "Session ended"})
+				return false
+			}
+			if watchedPost != 0 && !canStreamPost(userID.(uint), watchedPost) {
+				c.SSEvent("close", gin.H{"reason": "Post is no longer available"})
+				return false
+			}
+			return true
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Suggestion ranking weights and limits
+const (
+	weightFriendOfFriend = 3.0 // Per followed user who follows the candidate
+	weightSharedTopic    = 2.0 // Per topic both users follow
+	weightCoLike         = 1.0 // Per post both users liked
+	weightRecentPost     = 0.5 // Per post published in the activity window, capped
+	maxRecentPosts       = 5
+
+	suggestionActivityWindow = 30 * 24 * time.Hour
+	suggestionCandidateLimit = 200 // Per signal, so large graphs stay cheap
+)
+
+// suggestion is a candidate with the signals that put it there
+type suggestion struct {
+	userID          uint
+	friendsOfFriend int64
+	sharedTopics    int64
+	coLikes         int64
+	recentPosts     int64
+}
+
+// score combines the signals. Recent activity is weighted lightly and capped,
+// so prolific strangers rank below people connected through the graph but
+// still give new users someone to follow.
+func (s *suggestion) score() float64 {
+	recent := s.recentPosts
+	if recent > maxRecentPosts {
+		recent = maxRecentPosts
+	}
+	return weightFriendOfFriend*float64(s.friendsOfFriend) +
+		weightSharedTopic*float64(s.sharedTopics) +
+		weightCoLike*float64(s.coLikes) +
+		weightRecentPost*float64(recent)
+}
+
+// reasons explains the suggestion to the user
+func (s *suggestion) reasons() []string {
+	var reasons []string
+	if s.friendsOfFriend > 0 {
+		reasons = append(reasons, fmt.Sprintf("Followed by %d %s you follow", s.friendsOfFriend, plural(s.friendsOfFriend, "person", "people")))
+	}
+	if s.sharedTopics > 0 {
+		reasons = append(reasons, fmt.Sprintf("Follows %d %s you follow", s.sharedTopics, plural(s.sharedTopics, "topic", "topics")))
+	}
+	if s.coLikes > 0 {
+		reasons = append(reasons, fmt.Sprintf("Liked %d %s you liked", s.coLikes, plural(s.coLikes, "post", "posts")))
+	}
+	if s.recentPosts > 0 {
+		reasons = append(reasons, fmt.Sprintf("Published %d %s in the last 30 days", s.recentPosts, plural(s.recentPosts, "post", "posts")))
+	}
+	return reasons
+}
+
+// plural picks the singular or plural form for n
+func plural(n int64, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// signalRow is one row of a grouped signal query
+type signalRow struct {
+	UserID uint
+	Count  int64
+}
+
+// suggestable filters a signal query down to candidates in column that may be
+// suggested to @user: not the user, people they follow or asked to follow,
+// muted, blocked (either way) or dismissed users. It runs before each
+// signal's LIMIT so excluded accounts can't crowd out the rest.
+func suggestable(column string) string {
+	return column + ` <> @user
+		AND NOT EXISTS (SELECT 1 FROM follows x WHERE x.follower_id = @user AND x.following_id = ` + column + ` AND x.deleted_at IS NULL)
+		AND NOT EXISTS (SELECT 1 FROM follow_requests x WHERE x.requester_id = @user AND x.target_id = ` + column + `)
+		AND NOT EXISTS (SELECT 1 FROM blocks x WHERE (x.blocker_id = @user AND x.blocked_id = ` + column + `) OR (x.blocker_id = ` + column + ` AND x.blocked_id = @user))
+		AND NOT EXISTS (SELECT 1 FROM mutes x WHERE x.muter_id = @user AND x.muted_id = ` + column + `)
+		AND NOT EXISTS (SELECT 1 FROM suggestion_dismissals x WHERE x.user_id = @user AND x.dismissed_id = ` + column + `)`
+}
+
+// collectSuggestions gathers candidates for the user from the follow graph,
+// shared topics, co-likes and recent publishing
+func collectSuggestions(userID uint, now time.Time) (map[uint]*suggestion, error) {
+	args := map[string]interface{}{
+		"user":  userID,
+		"limit": suggestionCandidateLimit,
+		"since": now.Add(-suggestionActivityWindow),
+	}
+
+	signals := []struct {
+		query string
+		field func(*suggestion) *int64
+	}{
+		// People followed by the people the user follows
+		{`SELECT f2.following_id AS user_id, COUNT(DISTINCT f1.following_id) AS count
+			FROM follows f1
+			JOIN follows f2 ON f2.follower_id = f1.following_id AND f2.deleted_at IS NULL
+			WHERE f1.follower_id = @user AND f1.deleted_at IS NULL AND ` + suggestable("f2.following_id") + `
+			GROUP BY f2.following_id
+			ORDER BY count DESC
+			LIMIT @limit`, func(s *suggestion) *int64 { return &s.friendsOfFriend }},
+
+		// People following the same topics
+		{`SELECT t2.user_id, COUNT(*) AS count
+			FROM topic_follows t1
+			JOIN topic_follows t2 ON t2.topic_id = t1.topic_id AND t2.deleted_at IS NULL
+			WHERE t1.user_id = @user AND t1.deleted_at IS NULL AND ` + suggestable("t2.user_id") + `
+			GROUP BY t2.user_id
+			ORDER BY count DESC
+			LIMIT @limit`, func(s *suggestion) *int64 { return &s.sharedTopics }},
+
+		// People who liked the same posts
+		{`SELECT l2.user_id, COUNT(*) AS count
+			FROM likes l1
+			JOIN likes l2 ON l2.post_id = l1.post_id AND l2.deleted_at IS NULL
+			WHERE l1.user_id = @user AND l1.deleted_at IS NULL AND ` + suggestable("l2.user_id") + `
+			GROUP BY l2.user_id
+			ORDER BY count DESC
+			LIMIT @limit`, func(s *suggestion) *int64 { return &s.coLikes }},
+
+		// Recently active writers, which also covers users with no graph yet
+		{`SELECT p.author_id AS user_id, COUNT(*) AS count
+			FROM posts p
+			WHERE p.published = true AND p.unlisted = false AND p.published_at > @since AND p.deleted_at IS NULL
+				AND ` + suggestable("p.author_id") + `
+			GROUP BY p.author_id
+			ORDER BY count DESC
+			LIMIT @limit`, func(s *suggestion) *int64 { return &s.recentPosts }},
+	}
+
+	candidates := make(map[uint]*suggestion)
+	for _, signal := range signals {
+		var rows []signalRow
+		if err := config.DB.Raw(signal.query, args).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			s, ok := candidates[r.UserID]
+			if !ok {
+				s = &suggestion{userID: r.UserID}
+				candidates[r.UserID] = s
+			}
+			*signal.field(s) = r.Count
+		}
+	}
+
+	return candidates, nil
+}
+
+// GetSuggestedUsers returns people to follow, ranked by friends-of-friends,
+// shared topics, co-liked posts and recent activity, with the reasons for
+// each suggestion
+func GetSuggestedUsers(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "3"))
+	if limit < 1 || limit > 10 {
+		limit = 3
+	}
+
+	now := time.Now()
+	candidates, err := collectSuggestions(userID.(uint), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+		return
+	}
+
+	ranked := make([]*suggestion, 0, len(candidates))
+	for _, s := range candidates {
+		ranked = append(ranked, s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if si, sj := ranked[i].score(), ranked[j].score(); si != sj {
+			return si > sj
+		}
+		return ranked[i].userID < ranked[j].userID
+	})
+
+	// Load a few extra in case some candidates are deleted or suspended
+	ids := make([]uint, 0, limit*2)
+	for _, s := range ranked {
+		if len(ids) == cap(ids) {
+			break
+		}
+		ids = append(ids, s.userID)
+	}
+
+	var users []models.User
+	if len(ids) > 0 {
+		if err := config.DB.Where("id IN ?", ids).Find(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+			return
+		}
+	}
+	byID := make(map[uint]models.User, len(users))
+	for _, u := range users {
+		if !u.IsSuspended(now) {
+			byID[u.ID] = u
+		}
+	}
+
+	var picked []models.User
+	var pickedSignals []*suggestion
+	for _, s := range ranked {
+		if len(picked) == limit {
+			break
+		}
+		if u, ok := byID[s.userID]; ok {
+			picked = append(picked, u)
+			pickedSignals = append(pickedSignals, s)
+		}
+	}
+
+	type Suggestion struct {
+		UserSummary
+		Reasons []string `json:"reasons"`
+	}
+
+	result := make([]Suggestion, 0, len(picked))
+	for i, summary := range summarizeUsers(picked) {
+		result = append(result, Suggestion{UserSummary: summary, Reasons: pickedSignals[i].reasons()})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": result})
+}
+
+// DismissSuggestion stops a user from being suggested again
+func DismissSuggestion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	target, ok := findTargetUser(c, userID.(uint), "dismiss")
+	if !ok {
+		return
+	}
+
+	dismissal := models.SuggestionDismissal{UserID: userID.(uint), DismissedID: target.ID}
+	if err := config.DB.Where("user_id = ? AND dismissed_id = ?", dismissal.UserID, dismissal.DismissedID).
+		FirstOrCreate(&dismissal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss suggestion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suggestion dismissed"})
+}
@@ -21,14 +21,6 @@ func GetUserProfile(c *gin.Context) {
 		return
 	}
 
-	// Count followers (users following this user)
-	var followerCount int64
-	config.DB.Model(&models.Follow{}).Where("following_id = ?", user.ID).Count(&followerCount)
-
-	// Count following (users this user follows)
-	var followingCount int64
-	config.DB.Model(&models.Follow{}).Where("follower_id = ?", user.ID).Count(&followingCount)
-
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
 			"id":              user.ID,
@@ -36,8 +28,10 @@ func GetUserProfile(c *gin.Context) {
 			"full_name":       user.FullName,
 			"bio":             user.Bio,
 			"avatar":          user.Avatar,
-			"follower_count":  followerCount,
-			"following_count": followingCount,
+			"private":         user.Private,
+			"follower_count":  user.FollowerCount,
+			"following_count": user.FollowingCount,
+			"post_count":      user.PostCount,
 			"created_at":      user.CreatedAt,
 		},
 	})
@@ -54,6 +48,16 @@ func GetUserPosts(c *gin.Context) {
 		return
 	}
 
+	// Private accounts only show posts to approved followers
+	if !canViewPostsBy(viewerID(c), &user) {
+		c.JSON(http.StatusOK, gin.H{
+			"posts":   []models.Post{},
+			"total":   0,
+			"private": true,
+		})
+		return
+	}
+
 	// Get only published posts by this user
 	var posts []models.Post
 	if err := config.DB.Where("author_id = ? AND published = ?", user.ID, true).
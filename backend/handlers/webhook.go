@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+	"gin-quickstart/utils"
+	"gin-quickstart/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isAdmin reports whether the given user has admin rights
+func isAdmin(userID uint) bool {
+	var user models.User
+	if err := config.DB.Select("id", "is_admin").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return user.IsAdmin
+}
+
+// validateWebhookInput checks the endpoint URL and event names
+func validateWebhookInput(rawURL string, events []string) string {
+	if err := webhooks.ValidateURL(rawURL); err != nil {
+		return err.Error()
+	}
+	if len(events) == 0 {
+		return "At least one event is required"
+	}
+	for _, e := range events {
+		if !webhooks.IsValidEvent(e) {
+			return "Unknown event: " + e
+		}
+	}
+	return ""
+}
+
+// webhookPost is a post in webhook payloads. Global webhooks belong to other
+// users, so the author is reduced to their public identity.
+type webhookPost struct {
+	ID            uint       `json:"id"`
+	Title         string     `json:"title"`
+	Slug          string     `json:"slug"`
+	Content       string     `json:"content"`
+	Excerpt       string     `json:"excerpt"`
+	CoverImage    string     `json:"cover_image"`
+	Tags          string     `json:"tags"`
+	ReadTime      int        `json:"read_time"`
+	Unlisted      bool       `json:"unlisted"`
+	PublicationID *uint      `json:"publication_id"`
+	PublishedAt   *time.Time `json:"published_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Author        eventActor `json:"author"`
+}
+
+// newWebhookPost builds the payload for a published post with its Author loaded
+func newWebhookPost(post *models.Post) webhookPost {
+	return webhookPost{
+		ID:            post.ID,
+		Title:         post.Title,
+		Slug:          post.Slug,
+		Content:       post.Content,
+		Excerpt:       post.Excerpt,
+		CoverImage:    post.CoverImage,
+		Tags:          post.Tags,
+		ReadTime:      post.ReadTime,
+		Unlisted:      post.Unlisted,
+		PublicationID: post.PublicationID,
+		PublishedAt:   post.PublishedAt,
+		UpdatedAt:     post.UpdatedAt,
+		Author:        eventActor{ID: post.Author.ID, Username: post.Author.Username},
+	}
+}
+
+// webhookComment is a comment in webhook payloads, with the commenter reduced
+// to their public identity
+type webhookComment struct {
+	ID        uint       `json:"id"`
+	PostID    uint       `json:"post_id"`
+	ParentID  *uint      `json:"parent_id"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	Author    eventActor `json:"author"`
+}
+
+// newWebhookComment builds the payload for a comment with its User loaded
+func newWebhookComment(comment *models.Comment) webhookComment {
+	return webhookComment{
+		ID:        comment.ID,
+		PostID:    comment.PostID,
+		ParentID:  comment.ParentID,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+		Author:    eventActor{ID: comment.User.ID, Username: comment.User.Username},
+	}
+}
+
+// findOwnedWebhook loads a webhook the current user may manage (owner or admin)
+func findOwnedWebhook(c *gin.Context) (*models.Webhook, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return nil, false
+	}
+
+	var webhook models.Webhook
+	if err := config.DB.First(&webhook, webhookID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return nil, false
+	}
+
+	if webhook.UserID != userID.(uint) && !isAdmin(userID.(uint)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to manage this webhook"})
+		return nil, false
+	}
+
+	return &webhook, true
+}
+
+// CreateWebhook registers a new webhook endpoint. The signing secret is only
+// returned in this response.
+func CreateWebhook(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var input struct {
+		URL         string   `json:"url" binding:"required"`
+		Events      []string `json:"events" binding:"required"`
+		Description string   `json:"description"`
+		Global      bool     `json:"global"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if msg := validateWebhookInput(input.URL, input.Events); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// Only admins may receive events for every user
+	if input.Global && !isAdmin(userID.(uint)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can create global webhooks"})
+		return
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+		return
+	}
+
+	webhook := models.Webhook{
+		UserID:      userID.(uint),
+		URL:         input.URL,
+		Secret:      secret,
+		Events:      strings.Join(input.Events, ","),
+		Description: input.Description,
+		Global:      input.Global,
+		Active:      true,
+	}
+
+	if err := config.DB.Create(&webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"webhook": webhook, "secret": secret})
+}
+
+// GetWebhooks lists the authenticated user's webhooks
+func GetWebhooks(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var hooks []models.Webhook
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": hooks, "total": len(hooks), "events": webhooks.Events})
+}
+
+// GetAllWebhooks lists every webhook (admin only)
+func GetAllWebhooks(c *gin.Context) {
+	var hooks []models.Webhook
+	if err := config.DB.Order("created_at DESC").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": hooks, "total": len(hooks)})
+}
+
+// UpdateWebhook changes a webhook's URL, events, description or active flag
+func UpdateWebhook(c *gin.Context) {
+	webhook, ok := findOwnedWebhook(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		URL         *string   `json:"url"`
+		Events      *[]string `json:"events"`
+		Description *string   `json:"description"`
+		Active      *bool     `json:"active"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.URL != nil {
+		webhook.URL = *input.URL
+	}
+	events := strings.Split(webhook.Events, ",")
+	if input.Events != nil {
+		events = *input.Events
+	}
+	if msg := validateWebhookInput(webhook.URL, events); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	webhook.Events = strings.Join(events, ",")
+
+	if input.Description != nil {
+		webhook.Description = *input.Description
+	}
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+
+	if err := config.DB.Save(webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook})
+}
+
+// DeleteWebhook removes a webhook
+func DeleteWebhook(c *gin.Context) {
+	webhook, ok := findOwnedWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Delete(webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries returns the delivery log of a webhook
+func GetWebhookDeliveries(c *gin.Context) {
+	webhook, ok := findOwnedWebhook(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	query := config.DB.Where("webhook_id = ?", webhook.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Model(&models.WebhookDelivery{}).Count(&total)
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// RedeliverWebhook queues a manual redelivery of a past delivery
+func RedeliverWebhook(c *gin.Context) {
+	webhook, ok := findOwnedWebhook(c)
+	if !ok {
+		return
+	}
+
+	var original models.WebhookDelivery
+	if err := config.DB.Where("id = ? AND webhook_id = ?", c.Param("deliveryId"), webhook.ID).
+		First(&original).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	delivery, err := webhooks.Redeliver(original)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue redelivery"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"delivery": delivery})
+}
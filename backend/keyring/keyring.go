@@ -0,0 +1,232 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+	"gin-quickstart/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// reloadInterval is how often each instance re-reads the key ring, so
+	// keys created by another instance become verifiable here
+	reloadInterval = 5 * time.Minute
+
+	// prepublish is how long a new key sits in the JWKS before it signs
+	// anything. It must comfortably exceed reloadInterval and the JWKS
+	// cache lifetime.
+	prepublish = time.Hour
+
+	// retireGrace is extra time a retired key stays published after the
+	// longest-lived token it could have signed expires
+	retireGrace = time.Hour
+
+	// rotationLockID serializes rotation across instances
+	rotationLockID = 730035
+)
+
+// algorithm returns the configured signing algorithm (JWT_SIGNING_ALG).
+// Changing it takes effect at the next rotation.
+func algorithm() string {
+	if os.Getenv("JWT_SIGNING_ALG") == utils.AlgRS256 {
+		return utils.AlgRS256
+	}
+	return utils.AlgEdDSA
+}
+
+// rotationInterval returns how long each key signs tokens (JWT_KEY_ROTATION_DAYS, default 30)
+func rotationInterval() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("JWT_KEY_ROTATION_DAYS"))
+	if err != nil || days < 1 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Init makes sure a signing key exists and loads the key ring. It must run
+// before any token is issued or verified.
+func Init() error {
+	if err := Rotate(time.Now()); err != nil {
+		return err
+	}
+	return Load()
+}
+
+// StartRotation reloads the key ring in the background, creating the next
+// key ahead of its activation and pruning keys nothing can still verify
+func StartRotation() {
+	go func() {
+		ticker := time.NewTicker(reloadInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := Rotate(time.Now()); err != nil {
+				log.Printf("keyring: rotation failed: %v", err)
+			}
+			if err := Load(); err != nil {
+				log.Printf("keyring: reload failed: %v", err)
+			}
+		}
+	}()
+}
+
+// Rotate creates a new key when the current one is due for replacement and
+// deletes retired keys whose tokens have all expired
+func Rotate(now time.Time) error {
+	lifetime, err := utils.RefreshTokenLifetime()
+	if err != nil {
+		return err
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", rotationLockID).Error; err != nil {
+			return err
+		}
+
+		var keys []models.SigningKey
+		if err := tx.Order("activates_at ASC").Find(&keys).Error; err != nil {
+			return err
+		}
+
+		// Keys are retired when their successor activates
+		for i := 0; i+1 < len(keys); i++ {
+			retiredAt := keys[i+1].ActivatesAt
+			if retiredAt.Add(lifetime + retireGrace).Before(now) {
+				if err := tx.Delete(&keys[i]).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		switch {
+		case len(keys) == 0:
+			// First start: nothing can have cached a key yet, so sign immediately
+			return createKey(tx, now)
+		case !keys[len(keys)-1].ActivatesAt.Add(rotationInterval() - prepublish).After(now):
+			return createKey(tx, now.Add(prepublish))
+		}
+		return nil
+	})
+}
+
+// createKey generates a key with the configured algorithm
+func createKey(tx *gorm.DB, activatesAt time.Time) error {
+	alg := algorithm()
+
+	var private crypto.Signer
+	var err error
+	if alg == utils.AlgRS256 {
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	} else {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+	encrypted, err := utils.EncryptSecret(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	if err != nil {
+		return err
+	}
+
+	kid, err := utils.GenerateRandomToken(8)
+	if err != nil {
+		return err
+	}
+
+	key := models.SigningKey{
+		KID:         kid,
+		Algorithm:   alg,
+		PrivateKey:  encrypted,
+		ActivatesAt: activatesAt,
+	}
+	if err := tx.Create(&key).Error; err != nil {
+		return err
+	}
+
+	log.Printf("keyring: created %s key %s, active from %s", alg, kid, activatesAt.Format(time.RFC3339))
+	return nil
+}
+
+// Load reads every stored key into the in-memory key ring
+func Load() error {
+	var keys []models.SigningKey
+	if err := config.DB.Order("activates_at ASC").Find(&keys).Error; err != nil {
+		return err
+	}
+
+	ring := make([]*utils.SigningKey, 0, len(keys))
+	for _, key := range keys {
+		decoded, err := decodeKey(key)
+		if err != nil {
+			log.Printf("keyring: skipping key %s: %v", key.KID, err)
+			continue
+		}
+		ring = append(ring, decoded)
+	}
+	if len(ring) == 0 {
+		return errors.New("keyring: no usable signing keys")
+	}
+
+	utils.SetKeyRing(ring)
+	return nil
+}
+
+// decodeKey decrypts and parses a stored key
+func decodeKey(key models.SigningKey) (*utils.SigningKey, error) {
+	plaintext, err := utils.DecryptSecret(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode([]byte(plaintext))
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		if key.Algorithm != utils.AlgRS256 {
+			return nil, errors.New("algorithm does not match key type")
+		}
+	case ed25519.PrivateKey:
+		if key.Algorithm != utils.AlgEdDSA {
+			return nil, errors.New("algorithm does not match key type")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	return &utils.SigningKey{
+		KID:         key.KID,
+		Algorithm:   key.Algorithm,
+		Private:     private,
+		ActivatesAt: key.ActivatesAt,
+	}, nil
+}
@@ -1,34 +1,118 @@
 package main
 
 import (
+	"gin-quickstart/account"
 	"gin-quickstart/config"
+	"gin-quickstart/counters"
+	"gin-quickstart/digest"
 	"gin-quickstart/handlers"
+	"gin-quickstart/keyring"
+	"gin-quickstart/mailer"
 	"gin-quickstart/middleware"
 	"gin-quickstart/models"
+	"gin-quickstart/oidc"
+	"gin-quickstart/realtime"
+	"gin-quickstart/related"
+	"gin-quickstart/revocation"
+	"gin-quickstart/utils"
+	"gin-quickstart/webhooks"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Secrets protecting stored data have no fallback; refuse to start without them
+	if err := utils.CheckEncryptionKey(); err != nil {
+		log.Fatal(err)
+	}
+	if err := digest.CheckSigningKey(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Connect to database
 	config.ConnectDatabase()
 
+	// The token blacklist used to store whole JWTs; it is keyed by jti now and
+	// the old rows could only match tokens signed with the retired HS256 secret
+	if config.DB.Migrator().HasColumn(&models.BlacklistedToken{}, "token") {
+		if err := config.DB.Migrator().DropTable(&models.BlacklistedToken{}); err != nil {
+			log.Fatal("Failed to drop legacy token blacklist:", err)
+		}
+	}
+
+	// Webhook response bodies are no longer stored
+	if config.DB.Migrator().HasColumn(&models.WebhookDelivery{}, "response_body") {
+		if err := config.DB.Migrator().DropColumn(&models.WebhookDelivery{}, "response_body"); err != nil {
+			log.Fatal("Failed to drop webhook response bodies:", err)
+		}
+	}
+
+	// Engagement counters start from the existing rows; see counters.Reconcile
+	needCounters := !config.DB.Migrator().HasColumn(&models.User{}, "post_count")
+
 	// Auto-migrate database models
-	err := config.DB.AutoMigrate(&models.User{}, &models.Post{}, &models.RefreshToken{}, &models.BlacklistedToken{}, &models.Like{}, &models.Follow{}, &models.Bookmark{}, &models.Comment{}, &models.Topic{}, &models.TopicFollow{})
+	err := config.DB.AutoMigrate(&models.User{}, &models.Post{}, &models.RefreshToken{}, &models.BlacklistedToken{}, &models.Like{}, &models.Follow{}, &models.Bookmark{}, &models.Comment{}, &models.Topic{}, &models.TopicFollow{}, &models.Notification{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.Digest{}, &models.DigestPost{}, &models.Block{}, &models.Mute{}, &models.Report{}, &models.ModerationLog{}, &models.RateLimitBucket{}, &models.RecoveryCode{}, &models.UserIdentity{}, &models.OIDCLoginState{}, &models.PersonalAccessToken{}, &models.SigningKey{}, &models.DataExport{}, &models.DataExportArchive{}, &models.FollowRequest{}, &models.SuggestionDismissal{}, &models.RelatedPost{}, &models.RelatedRefresh{}, &models.Series{}, &models.SeriesPost{}, &models.SeriesFollow{}, &models.Publication{}, &models.PublicationMember{}, &models.PublicationFollow{}, &models.PostCollaborator{}, &models.DraftShareLink{}, &models.ReviewNote{}, &models.PostRevision{}, &models.Highlight{}, &models.BookmarkList{}, &models.BookmarkListFollow{}, &models.ReadingProgress{}, &models.CommentClap{}, &models.StreamTicket{})
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
+	if err := migrateBookmarksToLists(); err != nil {
+		log.Fatal("Failed to migrate bookmarks to lists:", err)
+	}
+	if needCounters {
+		if err := counters.Reconcile(config.DB); err != nil {
+			log.Fatal("Failed to compute engagement counters:", err)
+		}
+	}
 	log.Println("Database migration completed!")
 
+	// Load JWT signing keys and rotate them on a schedule
+	if err := keyring.Init(); err != nil {
+		log.Fatal("Failed to load signing keys:", err)
+	}
+	keyring.StartRotation()
+
+	// Load revoked tokens into memory and sweep expired token rows
+	if err := revocation.Init(); err != nil {
+		log.Fatal("Failed to load token revocations:", err)
+	}
+	revocation.StartSweeper()
+
+	// Start realtime broker for streaming endpoints
+	realtime.Init()
+
+	// Start background webhook delivery
+	webhooks.StartWorker()
+
+	// Start email digest scheduler
+	mailer.Init()
+	digest.StartScheduler()
+
+	// Build data exports and carry out scheduled account deletions
+	account.StartWorker()
+
+	// Precompute related posts
+	related.StartWorker()
+
+	// Load OpenID Connect providers for social login
+	oidc.Init()
+
+	// Set up rate limit storage
+	middleware.InitRateLimiter()
+
 	// Initialize Gin router
 	router := gin.Default()
+	if err := router.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
 
 	// CORS middleware - allows frontend to connect
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -49,48 +133,191 @@ func main() {
 		})
 	})
 
+	// Public keys for verifying our JWTs
+	router.GET("/.well-known/jwks.json", handlers.GetJWKS)
+
+	// Rate limits for abuse-prone routes
+	loginLimit := middleware.RateLimit(
+		middleware.RateLimitRule{Name: "login", Limit: 20, Window: time.Minute, Key: middleware.KeyByIP},
+		middleware.RateLimitRule{Name: "login", Limit: 10, Window: 15 * time.Minute, Key: middleware.KeyByAccount},
+	)
+	// Each start stores a login state, so unauthenticated starts are capped
+	oidcStartLimit := middleware.RateLimit(
+		middleware.RateLimitRule{Name: "oidc-start", Limit: 10, Window: 10 * time.Minute, Key: middleware.KeyByIP},
+	)
+	registerLimit := middleware.RateLimit(
+		middleware.RateLimitRule{Name: "register", Limit: 5, Window: time.Hour, Key: middleware.KeyByIP},
+	)
+	postLimit := middleware.RateLimit(
+		middleware.RateLimitRule{Name: "create-post", Limit: 10, Window: time.Hour, Key: middleware.KeyByUser},
+	)
+	commentLimit := middleware.RateLimit(
+		middleware.RateLimitRule{Name: "create-comment", Limit: 30, Window: 10 * time.Minute, Key: middleware.KeyByUser},
+		middleware.RateLimitRule{Name: "create-comment", Limit: 60, Window: 10 * time.Minute, Key: middleware.KeyByIP},
+	)
+
 	// API route group
 	api := router.Group("/api")
 	{
 		// Public authentication routes (no middleware)
 		auth := api.Group("/auth")
 		{
-			auth.POST("/register", handlers.Register)
-			auth.POST("/login", handlers.Login)
+			auth.POST("/register", registerLimit, handlers.Register)
+			auth.POST("/login", loginLimit, handlers.Login)
+			auth.POST("/login/2fa", loginLimit, handlers.LoginTwoFactor)
 			auth.POST("/refresh", handlers.RefreshTokenHandler)
 			auth.POST("/logout", handlers.Logout)
+
+			// OpenID Connect social login
+			auth.GET("/oidc/providers", handlers.GetOIDCProviders)
+			auth.GET("/oidc/:provider/start", oidcStartLimit, handlers.StartOIDCLogin)
+			auth.GET("/oidc/:provider/begin", oidcStartLimit, handlers.BeginOIDCLink)
+			auth.GET("/oidc/:provider/callback", loginLimit, handlers.OIDCCallback)
 		}
 
 		// Public post routes (read-only)
-		api.GET("/posts", handlers.GetPosts)
+		api.GET("/posts", middleware.OptionalAuthMiddleware(), handlers.GetPosts)
 		api.GET("/posts/staff-picks", handlers.GetStaffPicks)
-		api.GET("/posts/:slug", handlers.GetPost)
+		api.GET("/posts/:slug", middleware.OptionalAuthMiddleware(), handlers.GetPost)
+		api.GET("/posts/:slug/related", middleware.OptionalAuthMiddleware(), handlers.GetRelatedPosts)
+
+		api.GET("/posts/:slug/highlights", middleware.OptionalAuthMiddleware(), handlers.GetPostHighlights)
+
+		// Draft preview via secret share link
+		api.GET("/preview/:token", handlers.GetDraftPreview)
 
 		// Public user routes
 		api.GET("/users/:username", handlers.GetUserProfile)
-		api.GET("/users/:username/posts", handlers.GetUserPosts)
+		api.GET("/users/:username/posts", middleware.OptionalAuthMiddleware(), handlers.GetUserPosts)
+		api.GET("/users/:username/followers", middleware.OptionalAuthMiddleware(), handlers.GetFollowers)
+		api.GET("/users/:username/following", middleware.OptionalAuthMiddleware(), handlers.GetFollowing)
+		api.GET("/users/:username/series", middleware.OptionalAuthMiddleware(), handlers.GetUserSeries)
+		api.GET("/users/:username/lists", middleware.OptionalAuthMiddleware(), handlers.GetUserBookmarkLists)
+
+		// Public series route
+		api.GET("/series/:slug", middleware.OptionalAuthMiddleware(), handlers.GetSeries)
+
+		// Shared bookmark list route
+		api.GET("/lists/:id", middleware.OptionalAuthMiddleware(), handlers.GetBookmarkList)
+
+		// Public publication routes
+		api.GET("/publications/:slug", middleware.OptionalAuthMiddleware(), handlers.GetPublication)
+		api.GET("/publications/:slug/posts", middleware.OptionalAuthMiddleware(), handlers.GetPublicationPosts)
+		api.GET("/publications/:slug/posts/:postSlug", middleware.OptionalAuthMiddleware(), handlers.GetPublicationPost)
 
 		// Public like count route
 		api.GET("/likes/count/:postId", handlers.GetLikeCount)
 
 		// Public comment routes
-		api.GET("/comments/post/:postId", handlers.GetPostComments)
+		api.GET("/comments/post/:postId", middleware.OptionalAuthMiddleware(), handlers.GetPostComments)
+
+		// Public report reason categories
+		api.GET("/reports/reasons", handlers.GetReportReasons)
 
 		// Public topic routes
 		api.GET("/topics", handlers.GetTopics)
 		api.GET("/topics/:slug", handlers.GetTopic)
 
-		// Protected routes (require JWT authentication)
+		// Public digest unsubscribe (signed link from digest emails)
+		api.GET("/digest/unsubscribe", handlers.ConfirmUnsubscribeDigest)
+		api.POST("/digest/unsubscribe", handlers.UnsubscribeDigest)
+
+		// Live updates stream (JWT via header, or a single-use ?ticket= for EventSource)
+		api.POST("/stream/ticket", middleware.AuthMiddleware(), middleware.RequireScope(models.ScopeRead, models.ScopeRead), handlers.CreateStreamTicket)
+		api.GET("/stream", middleware.StreamAuthMiddleware(), middleware.RequireScope(models.ScopeRead, ""), handlers.Stream)
+
+		// Post management routes (personal access tokens need write:posts)
+		posts := api.Group("/")
+		posts.Use(middleware.AuthMiddleware(), middleware.RequireScope(models.ScopeRead, models.ScopeWritePosts))
+		{
+			posts.POST("/posts", postLimit, handlers.CreatePost)
+			posts.PUT("/posts/:id", handlers.UpdatePost)
+			posts.DELETE("/posts/:id", handlers.DeletePost)
+			posts.GET("/posts/my", handlers.GetMyPosts)
+
+			// Co-authors, reviewers and draft preview links
+			posts.GET("/posts/shared", handlers.GetSharedPosts)
+			posts.GET("/posts/:slug/collaborators", handlers.GetCollaborators)
+			posts.POST("/posts/:id/collaborators", handlers.AddCollaborator)
+			posts.PUT("/posts/:id/collaborators/:username", handlers.UpdateCollaborator)
+			posts.DELETE("/posts/:id/collaborators/:username", handlers.RemoveCollaborator)
+			posts.GET("/posts/:slug/share-links", handlers.GetShareLinks)
+			posts.POST("/posts/:id/share-links", handlers.CreateShareLink)
+			posts.DELETE("/posts/:id/share-links/:linkId", handlers.RevokeShareLink)
+
+			// Series management
+			posts.POST("/series", handlers.CreateSeries)
+			posts.PUT("/series/:id", handlers.UpdateSeries)
+			posts.DELETE("/series/:id", handlers.DeleteSeries)
+			posts.POST("/series/:id/posts", handlers.AddSeriesPost)
+			posts.DELETE("/series/:id/posts/:postId", handlers.RemoveSeriesPost)
+			posts.PUT("/series/:id/order", handlers.ReorderSeries)
+
+			// Publications: settings, members and editorial review
+			posts.POST("/publications", handlers.CreatePublication)
+			posts.PUT("/publications/:slug", handlers.UpdatePublication)
+			posts.DELETE("/publications/:slug", handlers.DeletePublication)
+			posts.POST("/publications/:slug/members", handlers.AddPublicationMember)
+			posts.PUT("/publications/:slug/members/:username", handlers.UpdatePublicationMember)
+			posts.DELETE("/publications/:slug/members/:username", handlers.RemovePublicationMember)
+			posts.GET("/publications/:slug/submissions", handlers.GetSubmissions)
+			posts.POST("/publications/:slug/submissions", handlers.SubmitToPublication)
+			posts.DELETE("/publications/:slug/submissions/:id", handlers.WithdrawSubmission)
+			posts.POST("/publications/:slug/submissions/:id/publish", handlers.PublishSubmission)
+			posts.POST("/publications/:slug/submissions/:id/reject", handlers.RejectSubmission)
+		}
+
+		// Comment routes (personal access tokens need write:comments)
+		comments := api.Group("/")
+		comments.Use(middleware.AuthMiddleware(), middleware.RequireScope(models.ScopeRead, models.ScopeWriteComments))
+		{
+			comments.POST("/comments/post/:postId", commentLimit, handlers.CreateComment)
+			comments.PUT("/comments/:id", handlers.UpdateComment)
+			comments.DELETE("/comments/:id", handlers.DeleteComment)
+
+			// Highlights
+			comments.POST("/posts/:id/highlights", handlers.CreateHighlight)
+			comments.DELETE("/highlights/:id", handlers.DeleteHighlight)
+
+			// Private review notes on drafts
+			comments.GET("/posts/:slug/notes", handlers.GetReviewNotes)
+			comments.POST("/posts/:id/notes", handlers.CreateReviewNote)
+			comments.PUT("/posts/:id/notes/:noteId", handlers.UpdateReviewNote)
+			comments.DELETE("/posts/:id/notes/:noteId", handlers.DeleteReviewNote)
+		}
+
+		// Account data and deletion (session only; personal access tokens are refused)
+		accountRoutes := api.Group("/user")
+		accountRoutes.Use(middleware.AuthMiddleware(), middleware.RequireScope("", ""))
+		{
+			accountRoutes.GET("/export", handlers.RequestDataExport)
+			accountRoutes.GET("/export/:id/download", handlers.DownloadDataExport)
+			accountRoutes.DELETE("/me", handlers.DeleteAccount)
+			accountRoutes.POST("/me/cancel-deletion", handlers.CancelAccountDeletion)
+		}
+
+		// Protected routes (require authentication; personal access tokens are read-only here)
 		protected := api.Group("/")
-		protected.Use(middleware.AuthMiddleware())
+		protected.Use(middleware.AuthMiddleware(), middleware.RequireScope(models.ScopeRead, ""))
 		{
 			protected.GET("/user/me", handlers.GetCurrentUser)
+			protected.POST("/auth/logout-all", handlers.LogoutAll)
 
-			// Post management routes
-			protected.POST("/posts", handlers.CreatePost)
-			protected.PUT("/posts/:id", handlers.UpdatePost)
-			protected.DELETE("/posts/:id", handlers.DeletePost)
-			protected.GET("/posts/my", handlers.GetMyPosts)
+			// Two-factor authentication routes
+			protected.POST("/auth/2fa/enroll", handlers.EnrollTwoFactor)
+			protected.POST("/auth/2fa/verify", handlers.VerifyTwoFactor)
+			protected.POST("/auth/2fa/disable", handlers.DisableTwoFactor)
+			protected.POST("/auth/2fa/recovery-codes", handlers.RegenerateRecoveryCodes)
+
+			// Linked identity routes
+			protected.POST("/auth/oidc/:provider/link", handlers.StartOIDCLink)
+			protected.GET("/user/identities", handlers.GetIdentities)
+			protected.DELETE("/user/identities/:id", handlers.UnlinkIdentity)
+
+			// Personal access token routes
+			protected.POST("/user/tokens", handlers.CreatePersonalAccessToken)
+			protected.GET("/user/tokens", handlers.GetPersonalAccessTokens)
+			protected.DELETE("/user/tokens/:id", handlers.RevokePersonalAccessToken)
 
 			// Bookmark routes
 			protected.POST("/bookmarks/:postId", handlers.AddBookmark)
@@ -98,22 +325,61 @@ func main() {
 			protected.GET("/bookmarks", handlers.GetBookmarks)
 			protected.GET("/bookmarks/check/:postId", handlers.CheckBookmark)
 
+			// Bookmark list routes
+			protected.GET("/lists", handlers.GetMyBookmarkLists)
+			protected.GET("/lists/following", handlers.GetFollowedBookmarkLists)
+			protected.POST("/lists", handlers.CreateBookmarkList)
+			protected.PUT("/lists/:id", handlers.UpdateBookmarkList)
+			protected.DELETE("/lists/:id", handlers.DeleteBookmarkList)
+			protected.POST("/lists/:id/items", handlers.AddBookmarkListItem)
+			protected.PUT("/lists/:id/items/:postId", handlers.UpdateBookmarkListItem)
+			protected.DELETE("/lists/:id/items/:postId", handlers.RemoveBookmarkListItem)
+			protected.PUT("/lists/:id/order", handlers.ReorderBookmarkList)
+			protected.POST("/lists/:id/follow", handlers.FollowBookmarkList)
+			protected.DELETE("/lists/:id/follow", handlers.UnfollowBookmarkList)
+
+			// Reading progress and history
+			protected.PUT("/posts/:id/progress", handlers.UpdateReadingProgress)
+			protected.GET("/user/history", handlers.GetReadingHistory)
+			protected.DELETE("/user/history", handlers.ClearReadingHistory)
+			protected.DELETE("/user/history/:postId", handlers.RemoveReadingHistoryItem)
+			protected.PUT("/user/history/pause", handlers.PauseReadingHistory)
+			protected.GET("/user/reading-stats", handlers.GetReadingStats)
+
 			// Like routes
 			protected.POST("/likes/:postId", handlers.AddLike)
 			protected.DELETE("/likes/:postId", handlers.RemoveLike)
 			protected.GET("/likes/check/:postId", handlers.CheckLike)
 
+			// Comment claps
+			protected.POST("/comments/:id/claps", handlers.ClapComment)
+			protected.DELETE("/comments/:id/claps", handlers.UnclapComment)
+
 			// Follow routes
 			protected.POST("/users/:username/follow", handlers.FollowUser)
 			protected.DELETE("/users/:username/follow", handlers.UnfollowUser)
 			protected.GET("/users/:username/following-check", handlers.CheckFollowing)
 			protected.GET("/feed/following", handlers.GetFollowingFeed)
+			protected.GET("/feed/for-you", handlers.GetForYouFeed)
 			protected.GET("/users/suggestions", handlers.GetSuggestedUsers)
+			protected.POST("/users/suggestions/:username/dismiss", handlers.DismissSuggestion)
+
+			// Private account routes
+			protected.PUT("/user/privacy", handlers.UpdatePrivacy)
+			protected.GET("/user/follow-requests", handlers.GetFollowRequests)
+			protected.POST("/user/follow-requests/:id/accept", handlers.AcceptFollowRequest)
+			protected.POST("/user/follow-requests/:id/reject", handlers.RejectFollowRequest)
 
-			// Comment routes
-			protected.POST("/comments/post/:postId", handlers.CreateComment)
-			protected.PUT("/comments/:id", handlers.UpdateComment)
-			protected.DELETE("/comments/:id", handlers.DeleteComment)
+			// Block and mute routes
+			protected.POST("/users/:username/block", handlers.BlockUser)
+			protected.DELETE("/users/:username/block", handlers.UnblockUser)
+			protected.POST("/users/:username/mute", handlers.MuteUser)
+			protected.DELETE("/users/:username/mute", handlers.UnmuteUser)
+			protected.GET("/user/blocks", handlers.GetBlockedUsers)
+			protected.GET("/user/mutes", handlers.GetMutedUsers)
+
+			// Report routes
+			protected.POST("/reports", handlers.CreateReport)
 
 			// Topic follow routes
 			protected.POST("/topics", handlers.CreateTopic)
@@ -121,6 +387,16 @@ func main() {
 			protected.DELETE("/topics/:slug/follow", handlers.UnfollowTopic)
 			protected.GET("/topics/:slug/follow-check", handlers.CheckTopicFollow)
 
+			// Series follow routes
+			protected.POST("/series/:id/follow", handlers.FollowSeries)
+			protected.DELETE("/series/:id/follow", handlers.UnfollowSeries)
+
+			// Publication follow routes
+			protected.POST("/publications/:slug/follow", handlers.FollowPublication)
+			protected.DELETE("/publications/:slug/follow", handlers.UnfollowPublication)
+			protected.GET("/user/publications", handlers.GetMyPublications)
+			protected.GET("/user/highlights", handlers.GetMyHighlights)
+
 			// Library routes
 			protected.GET("/user/liked", handlers.GetLikedPosts)
 			protected.GET("/user/comments", handlers.GetUserComments)
@@ -135,6 +411,40 @@ func main() {
 			// Following routes
 			protected.GET("/user/following/writers", handlers.GetFollowingWriters)
 			protected.GET("/user/topics", handlers.GetUserTopics)
+
+			// Email digest routes
+			protected.GET("/user/digest", handlers.GetDigestSettings)
+			protected.PUT("/user/digest", handlers.UpdateDigestSettings)
+
+			// Notification routes
+			protected.GET("/notifications", handlers.GetNotifications)
+			protected.PUT("/notifications/read-all", handlers.MarkAllNotificationsRead)
+			protected.PUT("/notifications/:id/read", handlers.MarkNotificationRead)
+
+			// Webhook routes
+			protected.POST("/webhooks", handlers.CreateWebhook)
+			protected.GET("/webhooks", handlers.GetWebhooks)
+			protected.PUT("/webhooks/:id", handlers.UpdateWebhook)
+			protected.DELETE("/webhooks/:id", handlers.DeleteWebhook)
+			protected.GET("/webhooks/:id/deliveries", handlers.GetWebhookDeliveries)
+			protected.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", handlers.RedeliverWebhook)
+		}
+
+		// Admin routes (require authentication and admin rights; tokens need the admin scope)
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(), middleware.RequireScope(models.ScopeAdmin, models.ScopeAdmin), middleware.AdminMiddleware())
+		{
+			admin.GET("/webhooks", handlers.GetAllWebhooks)
+
+			// Moderation queue
+			admin.GET("/reports", handlers.GetReports)
+			admin.GET("/reports/:id", handlers.GetReport)
+			admin.POST("/reports/:id/dismiss", handlers.DismissReport)
+			admin.POST("/reports/:id/hide", handlers.HideReportedContent)
+			admin.POST("/reports/:id/restore", handlers.RestoreReportedContent)
+			admin.POST("/reports/:id/suspend", handlers.SuspendReportedUser)
+			admin.POST("/users/:id/unsuspend", handlers.UnsuspendUser)
+			admin.GET("/moderation-log", handlers.GetModerationLog)
 		}
 	}
 
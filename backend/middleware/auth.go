@@ -3,11 +3,14 @@ package middleware
 import (
 	"gin-quickstart/config"
 	"gin-quickstart/models"
+	"gin-quickstart/revocation"
 	"gin-quickstart/utils"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
 )
 
 // AuthMiddleware validates JWT and attaches user info to context
@@ -33,38 +36,201 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
+		if !authenticate(c, parts[1]) {
+			return
+		}
 
-		// Validate JWT token
-		claims, err := utils.ValidateJWT(tokenString)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or expired token",
-			})
-			c.Abort()
+		c.Next()
+	}
+}
+
+// StreamAuthMiddleware is AuthMiddleware for streaming endpoints. Browsers'
+// EventSource cannot set headers, so a single-use ticket from
+// POST /api/stream/ticket may come from ?ticket= instead. Access tokens are
+// never accepted in the URL, where access logs would record them.
+func StreamAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2); len(parts) == 2 && parts[0] == "Bearer" {
+			if !authenticate(c, parts[1]) {
+				return
+			}
+			c.Next()
 			return
 		}
 
-		// Check if token is blacklisted
-		var blacklistedToken models.BlacklistedToken
-		if err := config.DB.Where("token = ?", tokenString).First(&blacklistedToken).Error; err == nil {
-			// Token found in blacklist
+		ticket := c.Query("ticket")
+		if ticket == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Token has been revoked",
+				"error": "Authorization header or ticket query parameter required",
 			})
 			c.Abort()
 			return
 		}
 
-		// Attach user info to context for downstream handlers
-		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
-		c.Set("username", claims.Username)
+		if !redeemStreamTicket(c, ticket) {
+			return
+		}
 
 		c.Next()
 	}
 }
 
+// redeemStreamTicket consumes a stream ticket and attaches the credential it
+// was issued for to the context. It aborts the request and returns false if
+// the ticket is unknown, used or expired.
+func redeemStreamTicket(c *gin.Context, ticketString string) bool {
+	now := time.Now()
+
+	// Deleting the row is what redeems it, so a ticket works only once
+	var ticket models.StreamTicket
+	result := config.DB.Clauses(clause.Returning{}).
+		Where("ticket_hash = ? AND expires_at > ?", utils.HashToken(ticketString), now).
+		Delete(&ticket)
+	if result.Error != nil || result.RowsAffected != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired stream ticket",
+		})
+		c.Abort()
+		return false
+	}
+
+	c.Set("user_id", ticket.UserID)
+	c.Set("email", ticket.Email)
+	c.Set("username", ticket.Username)
+	if ticket.PersonalTokenID != nil {
+		c.Set("personal_token_id", *ticket.PersonalTokenID)
+		c.Set("token_scopes", (&models.PersonalAccessToken{Scopes: ticket.Scopes}).ScopeList())
+	} else {
+		c.Set("token_id", ticket.TokenID)
+		c.Set("token_issued_at", ticket.TokenIssuedAt)
+	}
+	if ticket.TokenExpiresAt != nil {
+		c.Set("token_expires_at", *ticket.TokenExpiresAt)
+	}
+	return true
+}
+
+// authenticate validates an access token and attaches its claims to the
+// context. It aborts the request and returns false if the token is rejected.
+func authenticate(c *gin.Context, tokenString string) bool {
+	if strings.HasPrefix(tokenString, models.PersonalAccessTokenPrefix) {
+		return authenticatePersonalToken(c, tokenString)
+	}
+
+	// Validate JWT token
+	claims, err := utils.ValidateJWT(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired token",
+		})
+		c.Abort()
+		return false
+	}
+
+	// Check revocation from the in-memory cache. Suspending an account
+	// revokes its tokens, so this also rejects suspended users.
+	if revocation.IsRevoked(claims.ID, claims.UserID, claims.IssuedAt.Time) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Token has been revoked",
+		})
+		c.Abort()
+		return false
+	}
+
+	// Attach user info to context for downstream handlers
+	c.Set("user_id", claims.UserID)
+	c.Set("email", claims.Email)
+	c.Set("username", claims.Username)
+	c.Set("token_id", claims.ID)
+	c.Set("token_issued_at", claims.IssuedAt.Time)
+	c.Set("token_expires_at", claims.ExpiresAt.Time)
+
+	return true
+}
+
+// CredentialValid reports whether the credential that authenticated c is
+// still good: not expired or revoked, and its user not suspended. Long-lived
+// requests such as streams call it periodically, since the checks above only
+// run when they open.
+func CredentialValid(c *gin.Context, now time.Time) bool {
+	if expiresAt, ok := c.Get("token_expires_at"); ok && !now.Before(expiresAt.(time.Time)) {
+		return false
+	}
+
+	userID := c.GetUint("user_id")
+	if id, ok := c.Get("personal_token_id"); ok {
+		// Deleting a personal access token revokes it
+		var count int64
+		if err := config.DB.Model(&models.PersonalAccessToken{}).Where("id = ?", id).Count(&count).Error; err != nil || count == 0 {
+			return false
+		}
+	} else if revocation.IsRevoked(c.GetString("token_id"), userID, c.GetTime("token_issued_at")) {
+		return false
+	}
+
+	var user models.User
+	if err := config.DB.Select("id", "suspended_at", "suspended_until").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return !user.IsSuspended(now)
+}
+
+// authenticatePersonalToken is authenticate for personal access tokens. The
+// token's scopes are attached to the context for RequireScope.
+func authenticatePersonalToken(c *gin.Context, tokenString string) bool {
+	token, user, ok := lookupPersonalToken(tokenString)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or expired token",
+		})
+		c.Abort()
+		return false
+	}
+	if user.IsSuspended(time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Account suspended",
+		})
+		c.Abort()
+		return false
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("email", user.Email)
+	c.Set("username", user.Username)
+	c.Set("token_scopes", token.ScopeList())
+	c.Set("personal_token_id", token.ID)
+	if token.ExpiresAt != nil {
+		c.Set("token_expires_at", *token.ExpiresAt)
+	}
+
+	return true
+}
+
+// lookupPersonalToken resolves a personal access token to its owner and
+// records when it was last used
+func lookupPersonalToken(tokenString string) (*models.PersonalAccessToken, *models.User, bool) {
+	var token models.PersonalAccessToken
+	if err := config.DB.Where("token_hash = ?", utils.HashToken(tokenString)).First(&token).Error; err != nil {
+		return nil, nil, false
+	}
+	now := time.Now()
+	if token.IsExpired(now) {
+		return nil, nil, false
+	}
+
+	var user models.User
+	if err := config.DB.Select("id", "email", "username", "suspended_at", "suspended_until").First(&user, token.UserID).Error; err != nil {
+		return nil, nil, false
+	}
+
+	// Write last-used at most once a minute so busy scripts don't update the row on every call
+	config.DB.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", token.ID, now.Add(-time.Minute)).
+		Update("last_used_at", now)
+
+	return &token, &user, true
+}
+
 // OptionalAuthMiddleware validates JWT if present but doesn't require it
 func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -80,8 +246,19 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if strings.HasPrefix(parts[1], models.PersonalAccessTokenPrefix) {
+			if token, user, ok := lookupPersonalToken(parts[1]); ok && !user.IsSuspended(time.Now()) {
+				c.Set("user_id", user.ID)
+				c.Set("email", user.Email)
+				c.Set("username", user.Username)
+				c.Set("token_scopes", token.ScopeList())
+			}
+			c.Next()
+			return
+		}
+
 		claims, err := utils.ValidateJWT(parts[1])
-		if err == nil {
+		if err == nil && !revocation.IsRevoked(claims.ID, claims.UserID, claims.IssuedAt.Time) {
 			c.Set("user_id", claims.UserID)
 			c.Set("email", claims.Email)
 			c.Set("username", claims.Username)
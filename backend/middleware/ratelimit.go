@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc identifies who a request is counted against. Returning "" skips the rule.
+type KeyFunc func(c *gin.Context) string
+
+// RateLimitRule allows Limit requests per Window for each key on a route
+type RateLimitRule struct {
+	Name   string // Route identifier, part of the counter key
+	Limit  int
+	Window time.Duration
+	Key    KeyFunc
+}
+
+// TrustedProxies returns the proxies from TRUSTED_PROXIES (comma-separated
+// IPs or CIDRs) whose X-Forwarded-For header the router may believe. With
+// none, the client IP is always the connecting address, so clients cannot
+// dodge KeyByIP limits by sending their own forwarding headers.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// KeyByIP counts requests per client IP
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser counts requests per authenticated user, falling back to IP
+func KeyByUser(c *gin.Context) string {
+	if userID, exists := c.Get("user_id"); exists {
+		return "user:" + strconv.FormatUint(uint64(userID.(uint)), 10)
+	}
+	return KeyByIP(c)
+}
+
+// maxAccountBody caps how much of a request body KeyByAccount reads
+const maxAccountBody = 64 << 10
+
+// KeyByAccount counts requests per email address in the JSON body, so
+// password spraying against one account is throttled across many IPs
+func KeyByAccount(c *gin.Context) string {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxAccountBody))
+	// Restore what was read for the handler; an oversized body stays truncated
+	// and fails to bind
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Email == "" {
+		return ""
+	}
+	return "account:" + strings.ToLower(strings.TrimSpace(payload.Email))
+}
+
+// rateLimitResult is the outcome of checking one rule
+type rateLimitResult struct {
+	rule       RateLimitRule
+	key        string
+	window     time.Time // Start of the window the request was counted in
+	allowed    bool
+	remaining  int
+	reset      time.Time
+	retryAfter time.Duration
+}
+
+// RateLimit rejects requests that exceed any of the rules with 429 Too Many
+// Requests. Counts use a sliding window: the previous fixed window is weighted
+// by how much of it still overlaps the last Window of time.
+func RateLimit(rules ...RateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+
+		var results []rateLimitResult
+		for _, rule := range rules {
+			key := rule.Key(c)
+			if key == "" {
+				continue
+			}
+
+			result, err := hitRateLimit(rule, "ratelimit:"+rule.Name+":"+key, now)
+			if err != nil {
+				// Fail open: a broken store must not take the API down
+				log.Printf("ratelimit: %s check failed: %v", rule.Name, err)
+				continue
+			}
+			results = append(results, result)
+		}
+
+		if len(results) == 0 {
+			c.Next()
+			return
+		}
+
+		// Each rule counted the request as it checked it, so a parallel burst
+		// can't all pass before any of it is counted
+		rejected := false
+		for _, r := range results {
+			rejected = rejected || !r.allowed
+		}
+		if rejected {
+			// Only count requests that were let through
+			for _, r := range results {
+				if err := rateLimitStore.Undo(r.key, r.window); err != nil {
+					log.Printf("ratelimit: %s undo failed: %v", r.rule.Name, err)
+				}
+			}
+		}
+
+		// Report the most restrictive rule in the headers: the rejection with
+		// the longest wait, otherwise the rule with the fewest requests left
+		tightest := results[0]
+		for _, r := range results[1:] {
+			switch {
+			case !r.allowed && tightest.allowed:
+				tightest = r
+			case !r.allowed && r.retryAfter > tightest.retryAfter:
+				tightest = r
+			case r.allowed && tightest.allowed && r.remaining < tightest.remaining:
+				tightest = r
+			}
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(tightest.rule.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(tightest.remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(tightest.reset.Unix(), 10))
+
+		if !tightest.allowed {
+			retryAfter := int(math.Ceil(tightest.retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many requests, please try again later",
+				"retry_after": retryAfter,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// hitRateLimit counts the request against key, then decides from the
+// resulting sliding window estimate whether it fits
+func hitRateLimit(rule RateLimitRule, key string, now time.Time) (rateLimitResult, error) {
+	windowStart := now.Truncate(rule.Window)
+	previousStart := windowStart.Add(-rule.Window)
+
+	// current includes this request
+	current, previous, err := rateLimitStore.Hit(key, windowStart, previousStart, windowStart.Add(2*rule.Window))
+	if err != nil {
+		return rateLimitResult{}, err
+	}
+
+	elapsed := now.Sub(windowStart)
+	weight := 1 - float64(elapsed)/float64(rule.Window)
+	estimate := float64(previous)*weight + float64(current)
+	limit := float64(rule.Limit)
+
+	result := rateLimitResult{rule: rule, key: key, window: windowStart, reset: windowStart.Add(rule.Window)}
+
+	if estimate <= limit {
+		result.allowed = true
+		result.remaining = int(limit - math.Ceil(estimate))
+		if result.remaining < 0 {
+			result.remaining = 0
+		}
+		return result, nil
+	}
+
+	// Work out when the weighted previous window has decayed enough
+	if float64(current) > limit || previous == 0 {
+		result.retryAfter = result.reset.Sub(now)
+	} else {
+		needed := 1 - (limit-float64(current))/float64(previous)
+		result.retryAfter = time.Duration(needed*float64(rule.Window)) - elapsed
+	}
+	if result.retryAfter < time.Second {
+		result.retryAfter = time.Second
+	}
+	result.reset = now.Add(result.retryAfter)
+
+	return result, nil
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"gorm.io/gorm"
+)
+
+// RateLimitStore keeps per-window request counters
+type RateLimitStore interface {
+	// Hit adds one to the counter for key in the window starting at
+	// windowStart and returns its new value along with the count of the
+	// window starting at previousStart, as one atomic operation. The counter
+	// may be discarded after expiresAt.
+	Hit(key string, windowStart, previousStart, expiresAt time.Time) (current, previous int64, err error)
+	// Undo takes back a hit for a request that was rejected
+	Undo(key string, windowStart time.Time) error
+	// Sweep drops counters that expired before now
+	Sweep(now time.Time) error
+}
+
+// rateLimitStore is the store used by RateLimit, set up by InitRateLimiter
+var rateLimitStore RateLimitStore = NewMemoryRateLimitStore()
+
+// InitRateLimiter selects the store from RATE_LIMIT_STORE ("memory" or
+// "postgres") and starts sweeping expired counters
+func InitRateLimiter() {
+	switch os.Getenv("RATE_LIMIT_STORE") {
+	case "postgres":
+		rateLimitStore = &PostgresRateLimitStore{}
+		log.Println("Rate limit store: postgres")
+	default:
+		log.Println("Rate limit store: in-memory")
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for now := range ticker.C {
+			if err := rateLimitStore.Sweep(now); err != nil {
+				log.Printf("ratelimit: sweep failed: %v", err)
+			}
+		}
+	}()
+}
+
+type memoryBucket struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryRateLimitStore keeps counters in process; limits are per replica
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*memoryBucket)}
+}
+
+func bucketKey(key string, windowStart time.Time) string {
+	return key + "@" + windowStart.UTC().Format(time.RFC3339)
+}
+
+// Hit adds one to the counter and reads the previous window under one lock
+func (s *MemoryRateLimitStore) Hit(key string, windowStart, previousStart, expiresAt time.Time) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := bucketKey(key, windowStart)
+	b, ok := s.buckets[k]
+	if !ok {
+		b = &memoryBucket{expiresAt: expiresAt}
+		s.buckets[k] = b
+	}
+	b.count++
+
+	var previous int64
+	if p, ok := s.buckets[bucketKey(key, previousStart)]; ok {
+		previous = p.count
+	}
+	return b.count, previous, nil
+}
+
+// Undo takes one off the counter
+func (s *MemoryRateLimitStore) Undo(key string, windowStart time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.buckets[bucketKey(key, windowStart)]; ok && b.count > 0 {
+		b.count--
+	}
+	return nil
+}
+
+// Sweep drops expired counters
+func (s *MemoryRateLimitStore) Sweep(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, b := range s.buckets {
+		if b.expiresAt.Before(now) {
+			delete(s.buckets, k)
+		}
+	}
+	return nil
+}
+
+// PostgresRateLimitStore shares counters between replicas through the database
+type PostgresRateLimitStore struct{}
+
+// Hit upserts the counter and reads the previous window in one statement
+func (s *PostgresRateLimitStore) Hit(key string, windowStart, previousStart, expiresAt time.Time) (int64, int64, error) {
+	var counts struct {
+		Current  int64
+		Previous int64
+	}
+	err := config.DB.Raw(`WITH hit AS (
+			INSERT INTO rate_limit_buckets (key, window_start, count, expires_at)
+			VALUES (?, ?, 1, ?)
+			ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limit_buckets.count + 1
+			RETURNING count
+		)
+		SELECT hit.count AS current,
+			COALESCE((SELECT count FROM rate_limit_buckets WHERE key = ? AND window_start = ?), 0) AS previous
+		FROM hit`, key, windowStart, expiresAt, key, previousStart).Scan(&counts).Error
+	return counts.Current, counts.Previous, err
+}
+
+// Undo decrements the counter
+func (s *PostgresRateLimitStore) Undo(key string, windowStart time.Time) error {
+	return config.DB.Model(&models.RateLimitBucket{}).
+		Where("key = ? AND window_start = ? AND count > 0", key, windowStart).
+		UpdateColumn("count", gorm.Expr("count - 1")).Error
+}
+
+// Sweep deletes expired counters
+func (s *PostgresRateLimitStore) Sweep(now time.Time) error {
+	return config.DB.Where("expires_at < ?", now).Delete(&models.RateLimitBucket{}).Error
+}
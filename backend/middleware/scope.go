@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope limits what personal access tokens may do on a route group.
+// Safe methods (GET, HEAD) need readScope and everything else needs
+// writeScope; an empty scope means tokens are refused outright. Requests
+// authenticated with a session JWT carry no scopes and always pass. Must run
+// after AuthMiddleware.
+func RequireScope(readScope, writeScope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("token_scopes")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		required := writeScope
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			required = readScope
+		}
+
+		if required == "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Personal access tokens cannot be used for this action",
+			})
+			c.Abort()
+			return
+		}
+
+		for _, scope := range value.([]string) {
+			if scope == required {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Token is missing required scope: " + required,
+		})
+		c.Abort()
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateBookmarksToLists moves bookmarks saved before bookmark lists existed
+// into each user's built-in reading list. It is safe to run on every start.
+func migrateBookmarksToLists() error {
+	db := config.DB
+
+	// Bookmarks were unique per user and post; now they are unique per list
+	if db.Migrator().HasIndex(&models.Bookmark{}, "idx_user_post_bookmark") {
+		if err := db.Migrator().DropIndex(&models.Bookmark{}, "idx_user_post_bookmark"); err != nil {
+			return err
+		}
+	}
+
+	var userIDs []uint
+	if err := db.Unscoped().Model(&models.Bookmark{}).Where("list_id IS NULL").Distinct("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			list := models.BookmarkList{UserID: userID, Name: models.ReadingListName, Builtin: true}
+			if err := tx.Where("user_id = ? AND builtin = ?", userID, true).FirstOrCreate(&list).Error; err != nil {
+				return err
+			}
+
+			// Soft-deleted bookmarks were removed; drop them rather than carry them over
+			if err := tx.Unscoped().Where("user_id = ? AND list_id IS NULL AND deleted_at IS NOT NULL", userID).
+				Delete(&models.Bookmark{}).Error; err != nil {
+				return err
+			}
+
+			var bookmarks []models.Bookmark
+			if err := tx.Where("user_id = ? AND list_id IS NULL", userID).Order("created_at DESC").Find(&bookmarks).Error; err != nil {
+				return err
+			}
+
+			// Newest first, as the flat bookmark list was shown
+			for i, b := range bookmarks {
+				if err := tx.Model(&b).Updates(map[string]interface{}{"list_id": list.ID, "position": i}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -2,15 +2,14 @@ package models
 
 import (
 	"time"
-
-	"gorm.io/gorm"
 )
 
+// BlacklistedToken is a revoked access token, identified by its jti claim.
+// Rows are only needed until the token would have expired anyway.
 type BlacklistedToken struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	Token     string         `gorm:"unique;not null;index" json:"token"`
-	ExpiresAt time.Time      `gorm:"not null;index" json:"expires_at"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	JTI       string    `gorm:"column:jti;uniqueIndex;not null" json:"jti"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Block stops the blocked user from following or commenting on the blocker
+type Block struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	BlockerID uint      `gorm:"not null;index;uniqueIndex:idx_blocker_blocked" json:"blocker_id"`
+	BlockedID uint      `gorm:"not null;index;uniqueIndex:idx_blocker_blocked" json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	Blocker User `gorm:"foreignKey:BlockerID" json:"-"`
+	Blocked User `gorm:"foreignKey:BlockedID" json:"user"`
+}
+
+// Mute hides the muted user's posts from the muter's feeds
+type Mute struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	MuterID   uint      `gorm:"not null;index;uniqueIndex:idx_muter_muted" json:"muter_id"`
+	MutedID   uint      `gorm:"not null;index;uniqueIndex:idx_muter_muted" json:"muted_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	Muter User `gorm:"foreignKey:MuterID" json:"-"`
+	Muted User `gorm:"foreignKey:MutedID" json:"user"`
+}
@@ -6,11 +6,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// Bookmark puts a post in one of a user's bookmark lists. A post can sit in
+// several lists, each time with its own note and position.
 type Bookmark struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	UserID    uint           `gorm:"not null;index;uniqueIndex:idx_user_post_bookmark" json:"user_id"`
-	PostID    uint           `gorm:"not null;index;uniqueIndex:idx_user_post_bookmark" json:"post_id"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"`
+	ListID    *uint          `gorm:"index;uniqueIndex:idx_list_post_bookmark" json:"list_id"` // Nil only for rows from before lists existed
+	PostID    uint           `gorm:"not null;index;uniqueIndex:idx_list_post_bookmark" json:"post_id"`
+	Note      string         `gorm:"type:text" json:"note"`
+	Position  int            `gorm:"default:0" json:"position"`
 	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
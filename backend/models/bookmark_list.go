@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReadingListName is the name of every user's built-in bookmark list
+const ReadingListName = "Reading list"
+
+// BookmarkList is a named collection of bookmarked posts. Each user has one
+// built-in list, the reading list, which cannot be renamed or deleted.
+type BookmarkList struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	UserID      uint           `gorm:"not null;index;uniqueIndex:idx_user_builtin_list,where:builtin = true" json:"user_id"`
+	Name        string         `gorm:"not null" json:"name"`
+	Description string         `gorm:"type:text" json:"description"`
+	Public      bool           `gorm:"default:false" json:"public"`
+	Builtin     bool           `gorm:"default:false;uniqueIndex:idx_user_builtin_list,where:builtin = true" json:"builtin"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user"`
+}
+
+// BookmarkListFollow subscribes a user to someone else's public list
+type BookmarkListFollow struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index;uniqueIndex:idx_user_list" json:"user_id"`
+	ListID    uint      `gorm:"not null;index;uniqueIndex:idx_user_list" json:"list_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	List BookmarkList `gorm:"foreignKey:ListID" json:"list"`
+}
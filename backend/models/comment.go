@@ -16,8 +16,16 @@ type Comment struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Claps, kept in step with CommentClap rows
+	ClapCount    int `gorm:"default:0;index" json:"clap_count"`
+	ClapperCount int `gorm:"default:0" json:"clapper_count"`
+
 	// Relationships
 	User    User      `gorm:"foreignKey:UserID" json:"user"`
 	Post    Post      `gorm:"foreignKey:PostID" json:"-"`
 	Replies []Comment `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
 }
+
+// CommentCounters are the columns updated in place as readers clap. Saving a
+// whole comment omits them so a stale copy cannot overwrite them.
+var CommentCounters = []string{"clap_count", "clapper_count"}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// CommentClap is a user's claps for a comment
+type CommentClap struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index;uniqueIndex:idx_user_comment_clap" json:"user_id"`
+	CommentID uint      `gorm:"not null;index;uniqueIndex:idx_user_comment_clap" json:"comment_id"`
+	Count     int       `gorm:"not null;default:1" json:"count"` // Claps, 1 to MaxClaps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// Data export formats and statuses
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZIP  = "zip"
+
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusReady      = "ready"
+	ExportStatusFailed     = "failed"
+)
+
+// DataExport is a user's request for an archive of their data, built in the
+// background by the account worker
+type DataExport struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Format      string     `gorm:"not null" json:"format"`                       // json or zip
+	Status      string     `gorm:"not null;default:pending;index" json:"status"` // pending, processing, ready, failed
+	Size        int64      `json:"size"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at"` // Archive is deleted after this
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relationship
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// DataExportArchive holds a finished export's archive. It is kept in the
+// database, apart from DataExport so listing exports doesn't load it, so that
+// any replica can serve the download.
+type DataExportArchive struct {
+	ExportID  uint   `gorm:"primaryKey;autoIncrement:false"`
+	Data      []byte `gorm:"not null"`
+	CreatedAt time.Time
+}
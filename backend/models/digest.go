@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+type Digest struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Frequency string    `gorm:"not null" json:"frequency"`
+	PostCount int       `json:"post_count"`
+	Since     time.Time `json:"since"`
+	SentAt    time.Time `json:"sent_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DigestPost records a post already sent to a user so it never repeats
+type DigestPost struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DigestID  uint      `gorm:"not null;index" json:"digest_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_digest_post" json:"user_id"`
+	PostID    uint      `gorm:"not null;uniqueIndex:idx_user_digest_post" json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// DraftShareLink is a secret link that lets anyone holding it read an
+// unpublished draft. Only a SHA-256 hash of the link token is stored.
+type DraftShareLink struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	PostID      uint       `gorm:"not null;index" json:"post_id"`
+	CreatedByID uint       `gorm:"not null;index" json:"created_by_id"`
+	TokenHash   string     `gorm:"uniqueIndex;not null" json:"-"`
+	Hint        string     `gorm:"not null" json:"hint"` // Last characters of the token, for display
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	ViewCount   int        `gorm:"default:0" json:"view_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// IsActive reports whether the link can still be used
+func (l *DraftShareLink) IsActive(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// FollowRequest is a pending request to follow a private account. Accepting
+// it creates a Follow; accepting or rejecting removes the request.
+type FollowRequest struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	RequesterID uint      `gorm:"not null;index;uniqueIndex:idx_requester_target" json:"requester_id"`
+	TargetID    uint      `gorm:"not null;index;uniqueIndex:idx_requester_target" json:"target_id"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Relationships
+	Requester User `gorm:"foreignKey:RequesterID" json:"requester"`
+	Target    User `gorm:"foreignKey:TargetID" json:"-"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// Highlight visibility
+const (
+	HighlightPublic  = "public"
+	HighlightPrivate = "private"
+)
+
+// Highlight marks a passage of a post, with an optional note. The passage is
+// located by a text selector (quote, surrounding text and character offset)
+// recorded against a post version, and re-resolved when the content changes.
+type Highlight struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PostID      uint      `gorm:"not null;index" json:"post_id"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	PostVersion int       `gorm:"not null" json:"post_version"`
+	Quote       string    `gorm:"type:text;not null" json:"quote"`
+	Prefix      string    `json:"prefix"`
+	Suffix      string    `json:"suffix"`
+	StartOffset int       `gorm:"not null" json:"start_offset"` // In characters from the start of the content
+	Note        string    `gorm:"type:text" json:"note"`
+	Visibility  string    `gorm:"size:10;not null;default:public" json:"visibility"`
+	Orphaned    bool      `gorm:"default:false" json:"orphaned"` // The passage no longer exists in the post
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user"`
+	Post Post `gorm:"foreignKey:PostID" json:"-"`
+}
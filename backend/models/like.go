@@ -6,10 +6,15 @@ import (
 	"gorm.io/gorm"
 )
 
+// MaxClaps is how many times one user can clap for a post or comment
+const MaxClaps = 50
+
+// Like is a user's claps for a post; the row exists once they clap at least once
 type Like struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	UserID    uint           `gorm:"not null;index;uniqueIndex:idx_user_post" json:"user_id"`
 	PostID    uint           `gorm:"not null;index;uniqueIndex:idx_user_post" json:"post_id"`
+	Count     int            `gorm:"not null;default:1" json:"count"` // Claps, 1 to MaxClaps
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Notification struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"` // Recipient
+	ActorID   *uint          `gorm:"index" json:"actor_id"`         // User who triggered it; nil for system notices
+	Type      string         `gorm:"not null" json:"type"`          // follow, follow_request, follow_accepted, like, comment, reply, series_part, submission, submission_published, submission_rejected, publication_member, collaborator_added, review_note, comment_clap, report_resolved
+	Message   string         `json:"message,omitempty"`             // Text for system notices
+	PostID    *uint          `gorm:"index" json:"post_id"`
+	CommentID *uint          `json:"comment_id"`
+	Read      bool           `gorm:"default:false;index" json:"read"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Actor *User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Post  *Post `gorm:"foreignKey:PostID" json:"post,omitempty"`
+}
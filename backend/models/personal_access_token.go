@@ -0,0 +1,62 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Personal access token scopes
+const (
+	ScopeRead          = "read"
+	ScopeWritePosts    = "write:posts"
+	ScopeWriteComments = "write:comments"
+	ScopeAdmin         = "admin"
+)
+
+// TokenScopes lists every scope a personal access token may be granted
+var TokenScopes = []string{ScopeRead, ScopeWritePosts, ScopeWriteComments, ScopeAdmin}
+
+// PersonalAccessTokenPrefix marks bearer tokens that are personal access
+// tokens rather than JWTs
+const PersonalAccessTokenPrefix = "pat_"
+
+// PersonalAccessToken is a long-lived, user-created API credential for
+// scripts and CI. Only a SHA-256 hash of the token is stored.
+type PersonalAccessToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	TokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	Hint       string     `gorm:"not null" json:"hint"`   // Last characters of the token, for display
+	Scopes     string     `gorm:"not null" json:"scopes"` // Comma-separated scope names
+	ExpiresAt  *time.Time `json:"expires_at"`             // Nil means the token never expires
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationship
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// ScopeList returns the token's scopes
+func (t *PersonalAccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// IsExpired reports whether the token has passed its expiry
+func (t *PersonalAccessToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// IsValidScope reports whether scope is a known token scope
+func IsValidScope(scope string) bool {
+	for _, s := range TokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -25,4 +26,39 @@ type Post struct {
 	ScheduledAt *time.Time     `json:"scheduled_at"`                       // For scheduled posts
 	Unlisted    bool           `gorm:"default:false" json:"unlisted"`      // Hidden from feeds
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Publication the post belongs to and where it stands in editorial review
+	PublicationID    *uint        `gorm:"index" json:"publication_id"`
+	Publication      *Publication `gorm:"foreignKey:PublicationID" json:"publication,omitempty"`
+	SubmissionStatus string       `gorm:"size:20;index" json:"submission_status,omitempty"` // submitted, rejected, published
+	SubmittedAt      *time.Time   `json:"submitted_at,omitempty"`
+
+	// Content version, bumped on every content edit; see PostRevision
+	Version int `gorm:"default:1" json:"version"`
+
+	// Readers who scrolled past the read threshold; see ReadingProgress
+	ReadCount int `gorm:"default:0" json:"read_count"`
+
+	// Engagement counters, kept in step with the rows they count; see the
+	// counters package
+	LikeCount     int `gorm:"default:0" json:"like_count"` // Users who clapped
+	ClapCount     int `gorm:"default:0" json:"clap_count"` // Claps from all users
+	CommentCount  int `gorm:"default:0" json:"comment_count"`
+	BookmarkCount int `gorm:"default:0" json:"bookmark_count"` // Users who saved the post to any list
+}
+
+// PostCounters are the columns updated in place as readers engage with a
+// post. Saving a whole post omits them so a stale copy cannot overwrite them.
+var PostCounters = []string{"view_count", "read_count", "like_count", "clap_count", "comment_count", "bookmark_count"}
+
+// TagList returns the post's tags, trimmed and lowercased
+func (p *Post) TagList() []string {
+	var tags []string
+	for _, tag := range strings.Split(p.Tags, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
 }
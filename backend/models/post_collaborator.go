@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// Roles a collaborator can hold on someone else's post
+const (
+	CollaboratorRoleCoAuthor = "coauthor" // edits the post alongside the author
+	CollaboratorRoleReviewer = "reviewer" // reads the draft and leaves review notes
+)
+
+// PostCollaborator gives a user access to work on a post they did not write
+type PostCollaborator struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PostID      uint      `gorm:"not null;index;uniqueIndex:idx_post_collaborator" json:"post_id"`
+	UserID      uint      `gorm:"not null;index;uniqueIndex:idx_post_collaborator" json:"user_id"`
+	Role        string    `gorm:"size:20;not null" json:"role"`
+	InvitedByID uint      `gorm:"not null" json:"invited_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user"`
+	Post Post `gorm:"foreignKey:PostID" json:"-"`
+}
+
+// IsValidCollaboratorRole reports whether role is one of the collaborator roles
+func IsValidCollaboratorRole(role string) bool {
+	return role == CollaboratorRoleCoAuthor || role == CollaboratorRoleReviewer
+}
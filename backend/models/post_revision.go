@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// PostRevision is a snapshot of a post's content at one version
+type PostRevision struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PostID    uint      `gorm:"not null;uniqueIndex:idx_post_version" json:"post_id"`
+	Version   int       `gorm:"not null;uniqueIndex:idx_post_version" json:"version"`
+	Title     string    `json:"title"`
+	Content   string    `gorm:"type:text" json:"content"`
+	EditorID  *uint     `json:"editor_id"` // Nil for versions written before revisions were kept
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Roles a publication member can hold
+const (
+	PublicationRoleOwner  = "owner"  // manages members and settings
+	PublicationRoleEditor = "editor" // reviews, edits and publishes submissions
+	PublicationRoleWriter = "writer" // submits drafts for review
+)
+
+// Where a post submitted to a publication stands in review
+const (
+	SubmissionSubmitted = "submitted"
+	SubmissionRejected  = "rejected"
+	SubmissionPublished = "published"
+)
+
+// Publication is a team blog that several members publish under
+type Publication struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"not null" json:"name"`
+	Slug        string         `gorm:"unique;not null;index" json:"slug"`
+	Logo        string         `json:"logo"`
+	Description string         `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// PublicationMember gives a user a role in a publication
+type PublicationMember struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	PublicationID uint      `gorm:"not null;index;uniqueIndex:idx_publication_user" json:"publication_id"`
+	UserID        uint      `gorm:"not null;index;uniqueIndex:idx_publication_user" json:"user_id"`
+	Role          string    `gorm:"size:20;not null" json:"role"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Relationships
+	User        User        `gorm:"foreignKey:UserID" json:"user"`
+	Publication Publication `gorm:"foreignKey:PublicationID" json:"publication,omitempty"`
+}
+
+// CanEdit reports whether the member may review, edit and publish posts
+func (m *PublicationMember) CanEdit() bool {
+	return m.Role == PublicationRoleOwner || m.Role == PublicationRoleEditor
+}
+
+// IsValidPublicationRole reports whether role is one of the publication roles
+func IsValidPublicationRole(role string) bool {
+	switch role {
+	case PublicationRoleOwner, PublicationRoleEditor, PublicationRoleWriter:
+		return true
+	}
+	return false
+}
+
+// PublicationFollow subscribes a user to a publication's posts
+type PublicationFollow struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uint      `gorm:"not null;index;uniqueIndex:idx_user_publication" json:"user_id"`
+	PublicationID uint      `gorm:"not null;index;uniqueIndex:idx_user_publication" json:"publication_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
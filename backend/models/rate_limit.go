@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitBucket counts requests for one key in one fixed window.
+// Used by the Postgres rate limit store so limits hold across replicas.
+type RateLimitBucket struct {
+	Key         string    `gorm:"primaryKey" json:"key"`
+	WindowStart time.Time `gorm:"primaryKey" json:"window_start"`
+	Count       int64     `gorm:"not null;default:0" json:"count"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+}
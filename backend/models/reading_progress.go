@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReadingProgress is how far a user has read a post, so they can resume it
+// and find it again in their reading history
+type ReadingProgress struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index;uniqueIndex:idx_user_post_progress" json:"user_id"`
+	PostID     uint       `gorm:"not null;index;uniqueIndex:idx_user_post_progress" json:"post_id"`
+	Percent    float64    `gorm:"default:0" json:"percent"`     // Last reported scroll position, 0-100
+	MaxPercent float64    `gorm:"default:0" json:"max_percent"` // Furthest the user has read
+	Position   int        `gorm:"default:0" json:"position"`    // Client-defined offset to resume at
+	Read       bool       `gorm:"default:false" json:"read"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"index" json:"updated_at"` // Last time the user read the post
+
+	// Removing a post from the history soft-deletes its row, so a reader who
+	// comes back isn't counted in the post's views and reads a second time
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Post Post `gorm:"foreignKey:PostID" json:"post,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RecoveryCode is a single-use backup code for two-factor login
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Relationship
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// SetCode hashes and stores the code
+func (r *RecoveryCode) SetCode(code string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	r.CodeHash = string(hash)
+	return nil
+}
+
+// Matches reports whether code is this recovery code
+func (r *RecoveryCode) Matches(code string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(r.CodeHash), []byte(code)) == nil
+}
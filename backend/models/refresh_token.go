@@ -10,7 +10,7 @@ type RefreshToken struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	UserID    uint           `gorm:"not null;index" json:"user_id"`
 	Token     string         `gorm:"unique;not null" json:"token"`
-	ExpiresAt time.Time      `gorm:"not null" json:"expires_at"`
+	ExpiresAt time.Time      `gorm:"not null;index" json:"expires_at"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
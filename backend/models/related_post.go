@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Kinds of precomputed related posts
+const (
+	RelatedKindSimilar = "similar" // by other authors, ranked by similarity
+	RelatedKindAuthor  = "author"  // "more from this writer"
+)
+
+// RelatedPost is one precomputed recommendation shown under a post
+type RelatedPost struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	PostID        uint      `gorm:"not null;index:idx_related_post_kind" json:"post_id"`
+	Kind          string    `gorm:"size:20;not null;index:idx_related_post_kind" json:"kind"`
+	RelatedPostID uint      `gorm:"not null;index" json:"related_post_id"`
+	Position      int       `gorm:"not null" json:"position"`
+	Score         float64   `json:"score"`
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+}
+
+// RelatedRefresh records the related posts job's progress in a single row
+type RelatedRefresh struct {
+	ID        uint      `gorm:"primaryKey"`
+	RebuiltAt time.Time // Last recompute of every post
+	ChangesAt time.Time // Posts changed before this have been refreshed
+}
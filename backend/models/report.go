@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// Report target types
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+)
+
+// Report reasons
+var ReportReasons = []string{"spam", "harassment", "hate_speech", "violence", "sexual_content", "misinformation", "impersonation", "other"}
+
+// Report statuses
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusDismissed = "dismissed"
+	ReportStatusActioned  = "actioned"
+)
+
+type Report struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	ReporterID   uint       `gorm:"not null;index" json:"reporter_id"`
+	TargetType   string     `gorm:"not null;index:idx_report_target" json:"target_type"` // post, comment, user
+	TargetID     uint       `gorm:"not null;index:idx_report_target" json:"target_id"`
+	Reason       string     `gorm:"not null;index" json:"reason"`
+	Details      string     `gorm:"type:text" json:"details"`
+	Status       string     `gorm:"not null;default:open;index" json:"status"` // open, dismissed, actioned
+	ResolvedByID *uint      `json:"resolved_by_id"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+	Resolution   string     `gorm:"type:text" json:"resolution"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relationships
+	Reporter User `gorm:"foreignKey:ReporterID" json:"reporter"`
+}
+
+// ModerationLog records every action an admin takes
+type ModerationLog struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ModeratorID uint      `gorm:"not null;index" json:"moderator_id"`
+	Action      string    `gorm:"not null;index" json:"action"` // dismiss, hide_content, restore_content, suspend_user, unsuspend_user
+	ReportID    *uint     `gorm:"index" json:"report_id"`
+	TargetType  string    `gorm:"not null" json:"target_type"`
+	TargetID    uint      `gorm:"not null" json:"target_id"`
+	Note        string    `gorm:"type:text" json:"note"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Where a hidden post sat in its series, so restoring it puts it back
+	SeriesID       *uint `json:"series_id,omitempty"`
+	SeriesPosition *int  `json:"series_position,omitempty"`
+
+	// Relationships
+	Moderator User `gorm:"foreignKey:ModeratorID" json:"moderator"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReviewNote is a private note on a draft, visible only to the post's author
+// and collaborators. It can be anchored to a passage of the content.
+type ReviewNote struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	PostID    uint           `gorm:"not null;index" json:"post_id"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"`
+	Content   string         `gorm:"type:text;not null" json:"content"`
+	Quote     string         `gorm:"type:text" json:"quote"`  // Passage the note refers to; empty for general notes
+	Offset    int            `gorm:"default:0" json:"offset"` // Character offset of the quote in the content
+	Resolved  bool           `gorm:"default:false" json:"resolved"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user"`
+}
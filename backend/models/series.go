@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Series groups an author's posts into an ordered multi-part sequence
+type Series struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	AuthorID    uint           `gorm:"not null;index" json:"author_id"`
+	Title       string         `gorm:"not null" json:"title"`
+	Slug        string         `gorm:"unique;not null;index" json:"slug"`
+	Description string         `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Author User `gorm:"foreignKey:AuthorID" json:"author"`
+}
+
+// SeriesPost places a post in a series. A post belongs to at most one series.
+type SeriesPost struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SeriesID  uint      `gorm:"not null;index" json:"series_id"`
+	PostID    uint      `gorm:"not null;uniqueIndex" json:"post_id"`
+	Position  int       `gorm:"not null" json:"position"` // 0-based order within the series
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	Post Post `gorm:"foreignKey:PostID" json:"-"`
+}
+
+// SeriesFollow subscribes a user to notifications about new parts of a series
+type SeriesFollow struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index;uniqueIndex:idx_user_series" json:"user_id"`
+	SeriesID  uint      `gorm:"not null;index;uniqueIndex:idx_user_series" json:"series_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
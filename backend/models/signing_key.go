@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// SigningKey is one asymmetric key in the JWT key ring. A key signs new
+// tokens from ActivatesAt until the next key activates, and stays published
+// for verification until every token it signed has expired.
+type SigningKey struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	KID         string    `gorm:"column:kid;uniqueIndex;not null" json:"kid"`
+	Algorithm   string    `gorm:"not null" json:"algorithm"`          // RS256 or EdDSA
+	PrivateKey  string    `gorm:"type:text;not null" json:"-"`        // PKCS#8 PEM, encrypted with utils.EncryptSecret
+	ActivatesAt time.Time `gorm:"not null;index" json:"activates_at"` // Published ahead of this so verifiers see it first
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// StreamTicket is a short-lived, single-use credential for opening the live
+// updates stream. Browsers' EventSource cannot send headers, and a ticket in
+// the URL is worthless once redeemed, unlike an access token. It carries the
+// details of the credential it was issued for so the stream can keep
+// checking that credential. Only a SHA-256 hash of the ticket is stored.
+type StreamTicket struct {
+	ID              uint       `gorm:"primaryKey"`
+	TicketHash      string     `gorm:"uniqueIndex;not null"`
+	UserID          uint       `gorm:"not null;index"`
+	Email           string     `gorm:"not null"`
+	Username        string     `gorm:"not null"`
+	TokenID         string     // jti of the access token, for sessions
+	TokenIssuedAt   time.Time  // iat of the access token, for sessions
+	PersonalTokenID *uint      // Set when issued for a personal access token
+	Scopes          string     // Comma-separated personal access token scopes
+	TokenExpiresAt  *time.Time // When the underlying credential expires
+	ExpiresAt       time.Time  `gorm:"not null;index"`
+	CreatedAt       time.Time
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// SuggestionDismissal keeps a user out of someone's "who to follow" suggestions
+type SuggestionDismissal struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;index;uniqueIndex:idx_user_dismissed" json:"user_id"`
+	DismissedID uint      `gorm:"not null;uniqueIndex:idx_user_dismissed" json:"dismissed_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
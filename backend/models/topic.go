@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,3 +16,13 @@ type Topic struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
+
+// Matches reports whether one of the post's tags names this topic
+func (t *Topic) Matches(post *Post) bool {
+	for _, tag := range post.TagList() {
+		if tag == t.Slug || tag == strings.ToLower(t.Name) {
+			return true
+		}
+	}
+	return false
+}
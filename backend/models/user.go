@@ -15,9 +15,44 @@ type User struct {
 	FullName  string         `json:"full_name"`
 	Bio       string         `json:"bio"`
 	Avatar    string         `json:"avatar"`
+	IsAdmin   bool           `gorm:"default:false" json:"is_admin"`
+	Private   bool           `gorm:"default:false" json:"private"` // Posts visible to approved followers only
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // Soft delete support
+
+	// Email digest preferences
+	DigestFrequency string     `gorm:"default:off" json:"digest_frequency"` // off, daily, weekly
+	LastDigestAt    *time.Time `json:"-"`
+
+	// Moderation
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"` // nil with SuspendedAt set means indefinite
+	SuspensionReason string     `json:"-"`
+
+	// Brute-force protection
+	FailedLoginAttempts int        `gorm:"default:0" json:"-"`
+	LockedUntil         *time.Time `json:"-"`
+
+	// Two-factor authentication
+	TwoFactorEnabled bool   `gorm:"default:false" json:"two_factor_enabled"`
+	TOTPSecret       string `json:"-"` // Encrypted; set at enrollment, active once verified
+	TOTPLastStep     int64  `json:"-"` // Last accepted time step, prevents code replay
+
+	// Access tokens issued before this are rejected ("log out everywhere")
+	TokensInvalidBefore *time.Time `json:"-"`
+
+	// Account deletion; the account worker erases the account after the grace period
+	DeletionRequestedAt  *time.Time `json:"-"`
+	DeletionScheduledFor *time.Time `gorm:"index" json:"deletion_scheduled_for,omitempty"`
+
+	// Reading history; while paused, reading progress is not recorded
+	HistoryPaused bool `gorm:"default:false" json:"history_paused"`
+
+	// Counters, kept in step with the rows they count; see the counters package
+	FollowerCount  int `gorm:"default:0" json:"follower_count"`
+	FollowingCount int `gorm:"default:0" json:"following_count"`
+	PostCount      int `gorm:"default:0" json:"post_count"` // Published, listed posts
 }
 
 // HashPassword hashes the user's password using bcrypt
@@ -30,6 +65,14 @@ func (u *User) HashPassword(password string) error {
 	return nil
 }
 
+// IsSuspended reports whether the account is suspended at the given time
+func (u *User) IsSuspended(now time.Time) bool {
+	if u.SuspendedAt == nil {
+		return false
+	}
+	return u.SuspendedUntil == nil || u.SuspendedUntil.After(now)
+}
+
 // CheckPassword compares the provided password with the stored hash
 func (u *User) CheckPassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// UserIdentity links an external OIDC identity to a local user
+type UserIdentity struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Provider    string     `gorm:"not null;uniqueIndex:idx_provider_subject" json:"provider"`
+	Subject     string     `gorm:"not null;uniqueIndex:idx_provider_subject" json:"-"` // "sub" claim from the provider
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relationship
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// OIDCLoginState holds the per-attempt secrets of an authorization code flow
+// between the redirect to the provider and the callback
+type OIDCLoginState struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	State        string    `gorm:"unique;not null" json:"-"`
+	Provider     string    `gorm:"not null" json:"provider"`
+	Nonce        string    `gorm:"not null" json:"-"`
+	CodeVerifier string    `gorm:"not null" json:"-"`            // PKCE verifier
+	LinkUserID   *uint     `json:"-"`                            // Set when linking to a signed-in account
+	BrowserHash  string    `gorm:"not null;default:''" json:"-"` // Hash of the binding cookie; empty until bound
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Webhook struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	UserID      uint           `gorm:"not null;index" json:"user_id"`
+	URL         string         `gorm:"not null" json:"url"`
+	Secret      string         `gorm:"not null" json:"-"`      // HMAC-SHA256 signing key
+	Events      string         `gorm:"not null" json:"events"` // Comma-separated event names
+	Description string         `json:"description"`
+	Global      bool           `gorm:"default:false" json:"global"` // Admin-only: receives events for all users
+	Active      bool           `gorm:"default:true" json:"active"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+type WebhookDelivery struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	WebhookID     uint       `gorm:"not null;index" json:"webhook_id"`
+	Event         string     `gorm:"not null" json:"event"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Status        string     `gorm:"not null;default:pending;index" json:"status"` // pending, succeeded, failed
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	ResponseCode  int        `json:"response_code"`
+	Error         string     `gorm:"type:text" json:"error"`
+	NextAttemptAt *time.Time `gorm:"index" json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	RedeliveryOf  *uint      `json:"redelivery_of"` // Original delivery for manual redeliveries
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relationships
+	Webhook Webhook `gorm:"foreignKey:WebhookID" json:"-"`
+}
@@ -0,0 +1,215 @@
+package oidc_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-quickstart/oidc"
+	"gin-quickstart/oidc/oidctest"
+)
+
+const redirectURL = "https://app.example.com/api/auth/oidc/test/callback"
+
+func TestDiscover(t *testing.T) {
+	server := oidctest.NewServer()
+	defer server.Close()
+
+	doc, err := server.Provider("test", redirectURL).Discover()
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if doc.Issuer != server.URL || doc.TokenEndpoint != server.URL+"/token" || doc.JWKSURI != server.URL+"/jwks" {
+		t.Errorf("unexpected discovery document: %+v", doc)
+	}
+}
+
+func TestDiscoverRejectsBadDocuments(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  func(issuer string) oidc.Discovery
+	}{
+		{"issuer mismatch", func(issuer string) oidc.Discovery {
+			return oidc.Discovery{Issuer: "https://attacker.example.com", AuthorizationEndpoint: issuer + "/authorize", TokenEndpoint: issuer + "/token", JWKSURI: issuer + "/jwks"}
+		}},
+		{"missing token endpoint", func(issuer string) oidc.Discovery {
+			return oidc.Discovery{Issuer: issuer, AuthorizationEndpoint: issuer + "/authorize", JWKSURI: issuer + "/jwks"}
+		}},
+		{"missing JWKS", func(issuer string) oidc.Discovery {
+			return oidc.Discovery{Issuer: issuer, AuthorizationEndpoint: issuer + "/authorize", TokenEndpoint: issuer + "/token"}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(tt.doc(server.URL))
+			}))
+			defer server.Close()
+
+			provider := &oidc.Provider{Name: "test", Issuer: server.URL, ClientID: "client", RedirectURL: redirectURL}
+			if _, err := provider.Discover(); err == nil {
+				t.Error("expected discovery to fail")
+			}
+		})
+	}
+}
+
+func TestAuthURL(t *testing.T) {
+	server := oidctest.NewServer()
+	defer server.Close()
+
+	authURL, err := server.Provider("test", redirectURL).AuthURL("state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthURL: %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse %q: %v", authURL, err)
+	}
+	q := parsed.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             server.ClientID,
+		"redirect_uri":          redirectURL,
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        oidc.PKCEChallenge("verifier-1"),
+		"code_challenge_method": "S256",
+	}
+	for key, value := range want {
+		if got := q.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if !strings.HasPrefix(authURL, server.URL+"/authorize?") {
+		t.Errorf("auth URL %q does not use the discovered endpoint", authURL)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	server := oidctest.NewServer()
+	defer server.Close()
+	provider := server.Provider("test", redirectURL)
+
+	authURL, err := provider.AuthURL("state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthURL: %v", err)
+	}
+	code, state, err := server.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if state != "state-1" {
+		t.Errorf("state = %q, want state-1", state)
+	}
+
+	claims, err := provider.Exchange(code, "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.Subject != server.User.Subject || claims.Email != server.User.Email || !bool(claims.EmailVerified) {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	// Codes are single use
+	if _, err := provider.Exchange(code, "verifier-1", "nonce-1"); err == nil {
+		t.Error("expected a reused code to fail")
+	}
+}
+
+func TestExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(*oidctest.Server, *oidc.Provider)
+		verifier string
+		nonce    string
+	}{
+		{"wrong PKCE verifier", nil, "other-verifier", "nonce-1"},
+		{"wrong nonce", nil, "verifier-1", "other-nonce"},
+		{"wrong client secret", func(_ *oidctest.Server, p *oidc.Provider) { p.ClientSecret = "wrong" }, "verifier-1", "nonce-1"},
+		{"wrong audience", func(s *oidctest.Server, _ *oidc.Provider) { s.Audience = "other-client" }, "verifier-1", "nonce-1"},
+		{"expired token", func(s *oidctest.Server, _ *oidc.Provider) { s.TTL = -time.Minute }, "verifier-1", "nonce-1"},
+		{"unpublished signing key", func(s *oidctest.Server, _ *oidc.Provider) { s.SigningKey = newKey(t) }, "verifier-1", "nonce-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := oidctest.NewServer()
+			defer server.Close()
+			provider := server.Provider("test", redirectURL)
+			if tt.setup != nil {
+				tt.setup(server, provider)
+			}
+
+			authURL, err := provider.AuthURL("state-1", "nonce-1", "verifier-1")
+			if err != nil {
+				t.Fatalf("AuthURL: %v", err)
+			}
+			code, _, err := server.Authorize(authURL)
+			if err != nil {
+				t.Fatalf("Authorize: %v", err)
+			}
+
+			if claims, err := provider.Exchange(code, tt.verifier, tt.nonce); err == nil {
+				t.Errorf("expected exchange to fail, got claims %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	server := oidctest.NewServer()
+	defer server.Close()
+	provider := server.Provider("test", redirectURL)
+
+	token, err := server.IDToken(server.User, "nonce-1")
+	if err != nil {
+		t.Fatalf("IDToken: %v", err)
+	}
+
+	if _, err := provider.VerifyIDToken(token, "nonce-1"); err != nil {
+		t.Errorf("valid token rejected: %v", err)
+	}
+	if _, err := provider.VerifyIDToken(token, "nonce-2"); err == nil {
+		t.Error("expected nonce mismatch to fail")
+	}
+
+	// Flip a byte of the signature
+	tampered := token[:len(token)-2] + flip(token[len(token)-2]) + token[len(token)-1:]
+	if _, err := provider.VerifyIDToken(tampered, "nonce-1"); err == nil {
+		t.Error("expected a tampered signature to fail")
+	}
+
+	server.User.Subject = ""
+	noSubject, err := server.IDToken(server.User, "nonce-1")
+	if err != nil {
+		t.Fatalf("IDToken: %v", err)
+	}
+	if _, err := provider.VerifyIDToken(noSubject, "nonce-1"); err == nil {
+		t.Error("expected a token without a subject to fail")
+	}
+}
+
+func newKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func flip(b byte) string {
+	if b == 'A' {
+		return "B"
+	}
+	return "A"
+}
@@ -0,0 +1,61 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Event is a single message pushed to stream subscribers
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Broker fans events out to every subscriber of a topic
+type Broker interface {
+	Publish(topic string, event Event) error
+	Subscribe(topic string) (<-chan Event, func())
+	Close() error
+}
+
+// Bus is the broker used by handlers, set up by Init
+var Bus Broker = NewMemoryBroker()
+
+// Init selects the broker from REALTIME_BROKER ("memory" or "postgres")
+func Init() {
+	switch os.Getenv("REALTIME_BROKER") {
+	case "postgres":
+		broker, err := NewPostgresBroker()
+		if err != nil {
+			log.Fatal("Failed to start Postgres realtime broker:", err)
+		}
+		Bus = broker
+		log.Println("Realtime broker: postgres LISTEN/NOTIFY")
+	default:
+		log.Println("Realtime broker: in-memory")
+	}
+}
+
+// Publish encodes data and sends it on the given topic, logging failures
+func Publish(topic string, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("realtime: failed to encode %s event: %v", eventType, err)
+		return
+	}
+	if err := Bus.Publish(topic, Event{Type: eventType, Data: payload}); err != nil {
+		log.Printf("realtime: failed to publish %s event: %v", eventType, err)
+	}
+}
+
+// UserTopic is the topic carrying a user's notifications
+func UserTopic(userID uint) string {
+	return "user:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// PostTopic is the topic carrying comments and like counts for a post
+func PostTopic(postID uint) string {
+	return "post:" + strconv.FormatUint(uint64(postID), 10)
+}
@@ -0,0 +1,78 @@
+package realtime
+
+import "sync"
+
+// subscriberBuffer is how many events a slow subscriber may fall behind before drops
+const subscriberBuffer = 32
+
+// MemoryBroker delivers events within a single process
+type MemoryBroker struct {
+	mu     sync.RWMutex
+	topics map[string]map[chan Event]struct{}
+}
+
+// NewMemoryBroker creates an empty in-process broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{topics: make(map[string]map[chan Event]struct{})}
+}
+
+// Publish delivers the event to current subscribers, dropping it for full ones
+func (b *MemoryBroker) Publish(topic string, event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.topics[topic] {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is not keeping up; skip rather than block publishers
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel of events and a function that cancels it
+func (b *MemoryBroker) Subscribe(topic string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.topics[topic] == nil {
+		b.topics[topic] = make(map[chan Event]struct{})
+	}
+	b.topics[topic][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			// Close may already have closed the channel
+			if _, ok := b.topics[topic][ch]; !ok {
+				return
+			}
+			delete(b.topics[topic], ch)
+			if len(b.topics[topic]) == 0 {
+				delete(b.topics, topic)
+			}
+			close(ch)
+		})
+	}
+
+	return ch, cancel
+}
+
+// Close drops all subscribers
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for topic, subs := range b.topics {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(b.topics, topic)
+	}
+	return nil
+}
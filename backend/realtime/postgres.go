@@ -0,0 +1,126 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"gin-quickstart/config"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// notifyChannel is the Postgres channel shared by every replica
+const notifyChannel = "realtime_events"
+
+// envelope wraps an event with its topic for transport through NOTIFY
+type envelope struct {
+	Topic string `json:"topic"`
+	Event Event  `json:"event"`
+}
+
+// PostgresBroker fans events out across replicas using LISTEN/NOTIFY.
+// Each replica keeps a local MemoryBroker for its own subscribers.
+type PostgresBroker struct {
+	local  *MemoryBroker
+	cancel context.CancelFunc
+}
+
+// NewPostgresBroker connects a dedicated listener and starts relaying notifications
+func NewPostgresBroker() (*PostgresBroker, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	conn, err := listen(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	b := &PostgresBroker{local: NewMemoryBroker(), cancel: cancel}
+	go b.run(ctx, conn)
+	return b, nil
+}
+
+// Publish sends the event to every replica, including this one
+func (b *PostgresBroker) Publish(topic string, event Event) error {
+	payload, err := json.Marshal(envelope{Topic: topic, Event: event})
+	if err != nil {
+		return err
+	}
+	return config.DB.Exec("SELECT pg_notify(?, ?)", notifyChannel, string(payload)).Error
+}
+
+// Subscribe registers a local subscriber
+func (b *PostgresBroker) Subscribe(topic string) (<-chan Event, func()) {
+	return b.local.Subscribe(topic)
+}
+
+// Close stops the listener and drops local subscribers
+func (b *PostgresBroker) Close() error {
+	b.cancel()
+	return b.local.Close()
+}
+
+// run relays notifications to local subscribers, reconnecting on failure
+func (b *PostgresBroker) run(ctx context.Context, conn *pgx.Conn) {
+	backoff := time.Second
+
+	for {
+		if conn != nil {
+			err := b.relay(ctx, conn)
+			conn.Close(context.Background())
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("realtime: listener disconnected: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		var err error
+		conn, err = listen(ctx)
+		if err != nil {
+			log.Printf("realtime: reconnect failed: %v", err)
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = time.Second
+	}
+}
+
+// relay blocks on notifications until the connection fails
+func (b *PostgresBroker) relay(ctx context.Context, conn *pgx.Conn) error {
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var env envelope
+		if err := json.Unmarshal([]byte(notification.Payload), &env); err != nil {
+			log.Printf("realtime: dropping malformed notification: %v", err)
+			continue
+		}
+		b.local.Publish(env.Topic, env.Event)
+	}
+}
+
+// listen opens a dedicated connection subscribed to the notify channel
+func listen(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := pgx.Connect(ctx, config.DSN())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+	return conn, nil
+}
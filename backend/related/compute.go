@@ -0,0 +1,201 @@
+package related
+
+import (
+	"math"
+	"sort"
+)
+
+// Weights of each similarity signal in the combined score
+const (
+	tagWeight    = 1.0
+	coLikeWeight = 1.5
+	textWeight   = 1.0
+)
+
+// Limits on how many related posts are stored per post. More are kept than
+// the endpoint shows so some can be filtered out per viewer.
+const (
+	similarLimit = 12
+	authorLimit  = 6
+)
+
+// maxLikesPerUser skips readers who like so much that their likes say
+// little about which posts belong together
+const maxLikesPerUser = 1000
+
+// Doc is a published post as seen by the similarity model
+type Doc struct {
+	ID       uint
+	AuthorID uint
+	Title    string
+	Content  string
+	Tags     []string
+}
+
+// Match is one related post with its combined similarity score
+type Match struct {
+	PostID uint
+	Score  float64
+}
+
+// Result holds the related posts for a single post
+type Result struct {
+	Similar []Match // other authors, one post each
+	Author  []Match // more from the same author
+}
+
+// Compute scores every pair of posts that share a tag, a liker or a
+// distinctive term, and picks each post's related posts. likes maps a post
+// ID to the users who liked it.
+func Compute(docs []Doc, likes map[uint][]uint) map[uint]Result {
+	return ComputeFor(docs, likes, nil)
+}
+
+// ComputeFor is Compute for just the posts in targets, matched against all
+// of docs. A nil targets computes every post.
+func ComputeFor(docs []Doc, likes map[uint][]uint, targets map[uint]bool) map[uint]Result {
+	index := make(map[uint]int, len(docs))
+	counts := make([]map[string]int, len(docs))
+	byAuthor := make(map[uint][]int)
+	for i, d := range docs {
+		index[d.ID] = i
+		counts[i] = termCounts(d.Title, d.Content)
+		byAuthor[d.AuthorID] = append(byAuthor[d.AuthorID], i)
+	}
+	vectors := vectorize(counts)
+
+	// Inverted indexes so each post is only compared with posts it overlaps
+	termPosts := make(map[string][]int)
+	for i, v := range vectors {
+		for term := range v {
+			termPosts[term] = append(termPosts[term], i)
+		}
+	}
+	tagPosts := make(map[string][]int)
+	for i, d := range docs {
+		for _, tag := range d.Tags {
+			tagPosts[tag] = append(tagPosts[tag], i)
+		}
+	}
+	likers := make([][]uint, len(docs))
+	userPosts := make(map[uint][]int)
+	for postID, users := range likes {
+		i, ok := index[postID]
+		if !ok {
+			continue
+		}
+		likers[i] = users
+		for _, u := range users {
+			userPosts[u] = append(userPosts[u], i)
+		}
+	}
+
+	results := make(map[uint]Result, len(docs))
+	for i, d := range docs {
+		if targets != nil && !targets[d.ID] {
+			continue
+		}
+		scores := make(map[int]float64)
+
+		for term, weight := range vectors[i] {
+			for _, j := range termPosts[term] {
+				if j != i {
+					scores[j] += textWeight * weight * vectors[j][term]
+				}
+			}
+		}
+
+		sharedTags := make(map[int]int)
+		for _, tag := range d.Tags {
+			for _, j := range tagPosts[tag] {
+				if j != i {
+					sharedTags[j]++
+				}
+			}
+		}
+		for j, shared := range sharedTags {
+			union := len(d.Tags) + len(docs[j].Tags) - shared
+			scores[j] += tagWeight * float64(shared) / float64(union)
+		}
+
+		sharedLikers := make(map[int]int)
+		for _, u := range likers[i] {
+			if len(userPosts[u]) > maxLikesPerUser {
+				continue
+			}
+			for _, j := range userPosts[u] {
+				if j != i {
+					sharedLikers[j]++
+				}
+			}
+		}
+		for j, shared := range sharedLikers {
+			cosine := float64(shared) / math.Sqrt(float64(len(likers[i])*len(likers[j])))
+			scores[j] += coLikeWeight * cosine
+		}
+
+		results[d.ID] = Result{
+			Similar: pickSimilar(docs, d, scores),
+			Author:  pickAuthor(docs, d, byAuthor[d.AuthorID], scores),
+		}
+	}
+
+	return results
+}
+
+// ranked sorts post indexes by score, breaking ties toward newer posts so
+// the output is deterministic
+func ranked(docs []Doc, candidates []int, scores map[int]float64) []int {
+	sort.Slice(candidates, func(a, b int) bool {
+		sa, sb := scores[candidates[a]], scores[candidates[b]]
+		if sa != sb {
+			return sa > sb
+		}
+		return docs[candidates[a]].ID > docs[candidates[b]].ID
+	})
+	return candidates
+}
+
+// pickSimilar keeps the best-scoring post of each other author
+func pickSimilar(docs []Doc, d Doc, scores map[int]float64) []Match {
+	candidates := make([]int, 0, len(scores))
+	for j, s := range scores {
+		if s > 0 && docs[j].AuthorID != d.AuthorID {
+			candidates = append(candidates, j)
+		}
+	}
+
+	var matches []Match
+	seen := make(map[uint]bool)
+	for _, j := range ranked(docs, candidates, scores) {
+		if seen[docs[j].AuthorID] {
+			continue
+		}
+		seen[docs[j].AuthorID] = true
+		matches = append(matches, Match{PostID: docs[j].ID, Score: scores[j]})
+		if len(matches) == similarLimit {
+			break
+		}
+	}
+	return matches
+}
+
+// pickAuthor orders the author's other posts by similarity, falling back
+// to the newest when nothing is similar
+func pickAuthor(docs []Doc, d Doc, own []int, scores map[int]float64) []Match {
+	candidates := make([]int, 0, len(own))
+	for _, j := range own {
+		if docs[j].ID != d.ID {
+			candidates = append(candidates, j)
+		}
+	}
+
+	var matches []Match
+	for _, j := range ranked(docs, candidates, scores) {
+		matches = append(matches, Match{PostID: docs[j].ID, Score: scores[j]})
+		if len(matches) == authorLimit {
+			break
+		}
+	}
+	return matches
+}
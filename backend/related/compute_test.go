@@ -0,0 +1,110 @@
+package related
+
+import (
+	"fmt"
+	"testing"
+)
+
+// corpus has two authors writing about gardening and one about databases,
+// so every post has candidates both by its own author and by others
+func corpus() []Doc {
+	return []Doc{
+		{ID: 1, AuthorID: 10, Title: "Growing tomatoes", Content: "Tomatoes need sunlight, compost and steady watering.", Tags: []string{"gardening"}},
+		{ID: 2, AuthorID: 10, Title: "Pruning tomatoes", Content: "Pruning tomatoes keeps the compost bed tidy.", Tags: []string{"gardening"}},
+		{ID: 3, AuthorID: 10, Title: "Indexing tables", Content: "Postgres indexes speed up queries on large tables.", Tags: []string{"databases"}},
+		{ID: 4, AuthorID: 20, Title: "Tomatoes in pots", Content: "Potted tomatoes need compost and daily watering.", Tags: []string{"gardening"}},
+		{ID: 5, AuthorID: 20, Title: "Compost basics", Content: "Compost feeds tomatoes and every other vegetable.", Tags: []string{"gardening"}},
+		{ID: 6, AuthorID: 30, Title: "Query plans", Content: "Postgres query plans show which indexes queries use.", Tags: []string{"databases"}},
+	}
+}
+
+func TestCompute(t *testing.T) {
+	docs := corpus()
+	authors := make(map[uint]uint, len(docs))
+	for _, d := range docs {
+		authors[d.ID] = d.AuthorID
+	}
+
+	// Readers 100 and 101 like across authors, adding co-like signal
+	likes := map[uint][]uint{1: {100, 101}, 4: {100, 101}, 3: {100}, 6: {100}}
+
+	results := Compute(docs, likes)
+	if len(results) != len(docs) {
+		t.Fatalf("Compute returned %d results, want %d", len(results), len(docs))
+	}
+
+	for _, d := range docs {
+		result := results[d.ID]
+
+		seenAuthors := make(map[uint]bool)
+		for _, m := range result.Similar {
+			if m.PostID == d.ID {
+				t.Errorf("post %d: similar lists the post itself", d.ID)
+			}
+			if authors[m.PostID] == d.AuthorID {
+				t.Errorf("post %d: similar lists post %d by the same author", d.ID, m.PostID)
+			}
+			if seenAuthors[authors[m.PostID]] {
+				t.Errorf("post %d: similar lists author %d twice", d.ID, authors[m.PostID])
+			}
+			seenAuthors[authors[m.PostID]] = true
+		}
+
+		for _, m := range result.Author {
+			if m.PostID == d.ID {
+				t.Errorf("post %d: more from the author lists the post itself", d.ID)
+			}
+			if authors[m.PostID] != d.AuthorID {
+				t.Errorf("post %d: more from the author lists post %d by author %d", d.ID, m.PostID, authors[m.PostID])
+			}
+		}
+	}
+
+	// The gardening posts find each other ahead of the databases post
+	if similar := results[1].Similar; len(similar) == 0 || similar[0].PostID != 4 {
+		t.Errorf("post 1 similar = %+v, want post 4 first", similar)
+	}
+	if author := results[1].Author; len(author) != 2 || author[0].PostID != 2 {
+		t.Errorf("post 1 more from the author = %+v, want post 2 then post 3", author)
+	}
+	if similar := results[3].Similar; len(similar) == 0 || similar[0].PostID != 6 {
+		t.Errorf("post 3 similar = %+v, want post 6 first", similar)
+	}
+}
+
+func TestComputeFor(t *testing.T) {
+	docs := corpus()
+
+	results := ComputeFor(docs, nil, map[uint]bool{2: true, 99: true})
+	if len(results) != 1 {
+		t.Fatalf("ComputeFor returned %d results, want only post 2", len(results))
+	}
+
+	// Targets are matched against every post, as in a full Compute
+	if got, want := fmt.Sprint(results[2]), fmt.Sprint(Compute(docs, nil)[2]); got != want {
+		t.Errorf("ComputeFor = %s, want %s", got, want)
+	}
+}
+
+func TestVectorizeKeepsTopTerms(t *testing.T) {
+	// Every term appears in two of the three posts, so all of them get weight
+	var words []string
+	for i := 0; i < maxTermsPerDoc*2; i++ {
+		words = append(words, fmt.Sprintf("term%c%c", 'a'+i/26, 'a'+i%26))
+	}
+	counts := []map[string]int{{}, {}, termCounts("Unrelated", "")}
+	for i, w := range words {
+		counts[0][w] = i + 1 // Later terms are more frequent, so weigh more
+		counts[1][w] = 1
+	}
+
+	vectors := vectorize(counts)
+	if len(vectors[0]) != maxTermsPerDoc {
+		t.Fatalf("vector has %d terms, want %d", len(vectors[0]), maxTermsPerDoc)
+	}
+	for _, w := range words[len(words)-maxTermsPerDoc:] {
+		if _, ok := vectors[0][w]; !ok {
+			t.Errorf("vector dropped %q, one of the heaviest terms", w)
+		}
+	}
+}
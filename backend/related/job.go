@@ -0,0 +1,226 @@
+package related
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	pollInterval = 5 * time.Minute
+
+	// refreshLockID keeps two instances from recomputing at the same time
+	refreshLockID = 730042
+
+	// rebuildInterval is how often every post is recomputed. This picks up
+	// what refreshing changed posts misses: a new post entering older
+	// posts' lists, and term weights drifting as the corpus grows.
+	rebuildInterval = 24 * time.Hour
+
+	// storeBatch is how many posts' related posts are replaced per transaction
+	storeBatch = 200
+)
+
+// refreshInterval returns how often changed posts are recomputed
+// (RELATED_POSTS_REFRESH_MINUTES, default 30)
+func refreshInterval() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("RELATED_POSTS_REFRESH_MINUTES"))
+	if err != nil || minutes < 1 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// StartWorker keeps related posts up to date in the background
+func StartWorker() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := Refresh(time.Now()); err != nil {
+				log.Printf("related: refresh failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Refresh recomputes every post once the last rebuild is older than a day,
+// and otherwise the posts changed since the last run once the refresh
+// interval has passed. Each post's related posts are replaced in a
+// transaction, so readers see either its old or its new recommendations.
+// It does nothing when another instance is already refreshing.
+func Refresh(now time.Time) error {
+	// A session lock, held on one connection for the whole run
+	return config.DB.Connection(func(conn *gorm.DB) error {
+		var locked bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", refreshLockID).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", refreshLockID)
+
+		var state models.RelatedRefresh
+		if err := conn.FirstOrCreate(&state, models.RelatedRefresh{ID: 1}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		switch {
+		case state.RebuiltAt.Before(now.Add(-rebuildInterval)):
+			err = rebuild(conn, now)
+			state.RebuiltAt = now
+		case state.ChangesAt.Before(now.Add(-refreshInterval())):
+			err = refreshChanged(conn, state.ChangesAt, now)
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		state.ChangesAt = now
+		return conn.Save(&state).Error
+	})
+}
+
+// rebuild recomputes related posts for every listed post and drops those of
+// posts no longer listed
+func rebuild(db *gorm.DB, now time.Time) error {
+	docs, likes, err := load(db)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]uint, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+	}
+	if err := store(db, ids, Compute(docs, likes), now); err != nil {
+		return err
+	}
+
+	listed := db.Model(&models.Post{}).Select("id").Where("published = ? AND unlisted = ?", true, false)
+	return db.Where("post_id NOT IN (?)", listed).Delete(&models.RelatedPost{}).Error
+}
+
+// refreshChanged recomputes related posts for posts edited, published,
+// unpublished, deleted, liked or unliked since the last run. Posts that are
+// no longer listed lose theirs.
+func refreshChanged(db *gorm.DB, since, now time.Time) error {
+	var edited, liked []uint
+	if err := db.Unscoped().Model(&models.Post{}).
+		Where("updated_at > ? OR deleted_at > ?", since, since).
+		Pluck("id", &edited).Error; err != nil {
+		return err
+	}
+	if err := db.Unscoped().Model(&models.Like{}).
+		Where("created_at > ? OR deleted_at > ?", since, since).
+		Distinct().Pluck("post_id", &liked).Error; err != nil {
+		return err
+	}
+
+	targets := make(map[uint]bool, len(edited)+len(liked))
+	for _, id := range append(edited, liked...) {
+		targets[id] = true
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	docs, likes, err := load(db)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]uint, 0, len(targets))
+	for id := range targets {
+		ids = append(ids, id)
+	}
+	return store(db, ids, ComputeFor(docs, likes, targets), now)
+}
+
+// load reads every listed post and its likes
+func load(db *gorm.DB) ([]Doc, map[uint][]uint, error) {
+	var posts []models.Post
+	if err := db.Select("id, author_id, title, content, tags").
+		Where("published = ? AND unlisted = ?", true, false).
+		Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	docs := make([]Doc, len(posts))
+	for i := range posts {
+		docs[i] = Doc{
+			ID:       posts[i].ID,
+			AuthorID: posts[i].AuthorID,
+			Title:    posts[i].Title,
+			Content:  posts[i].Content,
+			Tags:     posts[i].TagList(),
+		}
+	}
+
+	var likeRows []models.Like
+	if err := db.Select("post_id, user_id").Find(&likeRows).Error; err != nil {
+		return nil, nil, err
+	}
+	likes := make(map[uint][]uint)
+	for _, l := range likeRows {
+		likes[l.PostID] = append(likes[l.PostID], l.UserID)
+	}
+
+	return docs, likes, nil
+}
+
+// store replaces the related posts of postIDs with their results, a batch
+// of posts per transaction. Posts without a result are left with none.
+func store(db *gorm.DB, postIDs []uint, results map[uint]Result, now time.Time) error {
+	for start := 0; start < len(postIDs); start += storeBatch {
+		batch := postIDs[start:min(start+storeBatch, len(postIDs))]
+
+		var rows []models.RelatedPost
+		for _, postID := range batch {
+			result := results[postID]
+			rows = appendRows(rows, postID, models.RelatedKindSimilar, result.Similar, now)
+			rows = appendRows(rows, postID, models.RelatedKindAuthor, result.Author, now)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("post_id IN ?", batch).Delete(&models.RelatedPost{}).Error; err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+			return tx.CreateInBatches(rows, 500).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendRows adds one stored row per match, keeping the ranked order
+func appendRows(rows []models.RelatedPost, postID uint, kind string, matches []Match, now time.Time) []models.RelatedPost {
+	for i, m := range matches {
+		rows = append(rows, models.RelatedPost{
+			PostID:        postID,
+			Kind:          kind,
+			RelatedPostID: m.PostID,
+			Position:      i,
+			Score:         m.Score,
+			CreatedAt:     now,
+		})
+	}
+	return rows
+}
@@ -0,0 +1,125 @@
+package related
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// stopwords are common English words that say nothing about a post's subject
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`about above after again against all also and any are because been
+		before being below between both but can could did does doing down during each few for from
+		further had has have having her here hers herself him himself his how into its itself just
+		more most not now off once only other our ours ourselves out over own same she should some
+		such than that the their theirs them themselves then there these they this those through too
+		under until very was were what when where which while who whom why will with would you your
+		yours yourself yourselves one two get got like make made use used using way well really much
+		many even still may might must shall let lets thing things`) {
+		stopwords[w] = true
+	}
+}
+
+// titleBoost is how many times a title word counts relative to the body
+const titleBoost = 2
+
+// maxTermsPerDoc caps the terms kept in each post's vector. A post's most
+// distinctive terms carry its subject, and the cap bounds how many posts
+// Compute reaches through shared terms.
+const maxTermsPerDoc = 40
+
+// tokenize lowercases text and splits it into words, dropping short words,
+// numbers and stopwords. Markdown punctuation falls out as separators.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := words[:0]
+	for _, w := range words {
+		if len([]rune(w)) < 3 || stopwords[w] || isNumber(w) {
+			continue
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+func isNumber(w string) bool {
+	for _, r := range w {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// termCounts counts each term in a post, with title words boosted
+func termCounts(title, content string) map[string]int {
+	counts := make(map[string]int)
+	for _, t := range tokenize(title) {
+		counts[t] += titleBoost
+	}
+	for _, t := range tokenize(content) {
+		counts[t]++
+	}
+	return counts
+}
+
+// vectorize turns per-post term counts into unit-length TF-IDF vectors of
+// at most maxTermsPerDoc terms. Terms that appear in a single post are left
+// out since they cannot be shared, and terms in every post get no weight
+// from the IDF.
+func vectorize(counts []map[string]int) []map[string]float64 {
+	df := make(map[string]int)
+	for _, c := range counts {
+		for term := range c {
+			df[term]++
+		}
+	}
+
+	n := float64(len(counts))
+	vectors := make([]map[string]float64, len(counts))
+	for i, c := range counts {
+		v := make(map[string]float64, len(c))
+		for term, count := range c {
+			d := df[term]
+			if d < 2 || d == len(counts) {
+				continue
+			}
+			v[term] = (1 + math.Log(float64(count))) * math.Log(n/float64(d))
+		}
+
+		if len(v) > maxTermsPerDoc {
+			terms := make([]string, 0, len(v))
+			for term := range v {
+				terms = append(terms, term)
+			}
+			sort.Slice(terms, func(a, b int) bool {
+				if v[terms[a]] != v[terms[b]] {
+					return v[terms[a]] > v[terms[b]]
+				}
+				return terms[a] < terms[b]
+			})
+			for _, term := range terms[maxTermsPerDoc:] {
+				delete(v, term)
+			}
+		}
+
+		var norm float64
+		for _, weight := range v {
+			norm += weight * weight
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for term := range v {
+				v[term] /= norm
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
@@ -0,0 +1,158 @@
+package revocation
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+	"gin-quickstart/utils"
+
+	"gorm.io/gorm"
+)
+
+// cache mirrors the unexpired revocations in the database so checking an
+// access token needs no query. Every instance keeps its copy current by
+// listening for revocation notifications.
+var cache = struct {
+	sync.RWMutex
+	tokens map[string]time.Time // jti -> token expiry
+	users  map[uint]time.Time   // user ID -> tokens issued before this are revoked
+}{
+	tokens: map[string]time.Time{},
+	users:  map[uint]time.Time{},
+}
+
+// IsRevoked reports whether an access token has been revoked, either on its
+// own or by a per-user cutoff covering its issue time
+func IsRevoked(jti string, userID uint, issuedAt time.Time) bool {
+	cache.RLock()
+	defer cache.RUnlock()
+
+	if _, ok := cache.tokens[jti]; ok {
+		return true
+	}
+	if before, ok := cache.users[userID]; ok && issuedAt.Before(before) {
+		return true
+	}
+	return false
+}
+
+// RevokeToken revokes a single access token until it expires
+func RevokeToken(jti string, userID uint, expiresAt time.Time) error {
+	row := models.BlacklistedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt}
+	if err := config.DB.Where("jti = ?", jti).FirstOrCreate(&row).Error; err != nil {
+		return err
+	}
+
+	applyToken(jti, expiresAt)
+	return notify(config.DB, message{JTI: jti, UserID: userID, ExpiresAt: expiresAt})
+}
+
+// RevokeUser revokes every access token the user was issued before the given
+// time and deletes their refresh tokens. Run it inside the caller's
+// transaction, then call ApplyUser with the same arguments once it commits;
+// other instances are notified by the commit itself. Nothing is cached
+// before then, so a rolled-back revocation never takes effect.
+func RevokeUser(tx *gorm.DB, userID uint, before time.Time) error {
+	before = cutoff(before)
+
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("tokens_invalid_before", before).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RefreshToken{}).Error; err != nil {
+		return err
+	}
+
+	return notify(tx, message{UserID: userID, Before: &before})
+}
+
+// ApplyUser updates this instance's cache after a transaction that called
+// RevokeUser has committed, without waiting for the notification
+func ApplyUser(userID uint, before time.Time) {
+	applyUser(userID, cutoff(before))
+}
+
+// cutoff rounds a revocation time up to the next second, since iat has
+// second precision and tokens from the same second must be covered
+func cutoff(before time.Time) time.Time {
+	return before.Truncate(time.Second).Add(time.Second)
+}
+
+// applyToken records a revoked jti in the cache
+func applyToken(jti string, expiresAt time.Time) {
+	cache.Lock()
+	cache.tokens[jti] = expiresAt
+	cache.Unlock()
+}
+
+// applyUser records a per-user cutoff, keeping the later one
+func applyUser(userID uint, before time.Time) {
+	cache.Lock()
+	if current, ok := cache.users[userID]; !ok || before.After(current) {
+		cache.users[userID] = before
+	}
+	cache.Unlock()
+}
+
+// reload replaces the cache with the revocations that can still match an
+// unexpired token
+func reload() error {
+	lifetime, err := utils.AccessTokenLifetime()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	var tokens []models.BlacklistedToken
+	if err := config.DB.Select("jti", "expires_at").Where("expires_at > ?", now).Find(&tokens).Error; err != nil {
+		return err
+	}
+
+	// Older cutoffs only cover tokens that have expired since
+	var users []models.User
+	if err := config.DB.Select("id", "tokens_invalid_before").
+		Where("tokens_invalid_before > ?", now.Add(-lifetime)).Find(&users).Error; err != nil {
+		return err
+	}
+
+	tokenMap := make(map[string]time.Time, len(tokens))
+	for _, t := range tokens {
+		tokenMap[t.JTI] = t.ExpiresAt
+	}
+	userMap := make(map[uint]time.Time, len(users))
+	for _, u := range users {
+		userMap[u.ID] = *u.TokensInvalidBefore
+	}
+
+	cache.Lock()
+	cache.tokens = tokenMap
+	cache.users = userMap
+	cache.Unlock()
+
+	log.Printf("revocation: loaded %d revoked tokens and %d user cutoffs", len(tokens), len(users))
+	return nil
+}
+
+// prune drops cache entries that can no longer match an unexpired token
+func prune(now time.Time) {
+	lifetime, err := utils.AccessTokenLifetime()
+	if err != nil {
+		return
+	}
+
+	cache.Lock()
+	defer cache.Unlock()
+
+	for jti, expiresAt := range cache.tokens {
+		if !expiresAt.After(now) {
+			delete(cache.tokens, jti)
+		}
+	}
+	for userID, before := range cache.users {
+		if !before.After(now.Add(-lifetime)) {
+			delete(cache.users, userID)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"gin-quickstart/config"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// notifyChannel is the Postgres channel revocations are announced on
+const notifyChannel = "token_revocations"
+
+// message is a revocation announced to every instance. It carries either a
+// single token (JTI) or a per-user cutoff (Before).
+type message struct {
+	JTI       string     `json:"jti,omitempty"`
+	UserID    uint       `json:"user_id"`
+	ExpiresAt time.Time  `json:"expires_at,omitempty"`
+	Before    *time.Time `json:"before,omitempty"`
+}
+
+// notify announces a revocation. Inside a transaction Postgres holds the
+// notification until commit.
+func notify(db *gorm.DB, msg message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return db.Exec("SELECT pg_notify(?, ?)", notifyChannel, string(payload)).Error
+}
+
+// stop ends the listener started by Init
+var stop context.CancelFunc = func() {}
+
+// Init loads the revocation cache and keeps it current in the background
+func Init() error {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	conn, err := listen(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	// Load after LISTEN so nothing revoked in between is missed
+	if err := reload(); err != nil {
+		conn.Close(context.Background())
+		cancel()
+		return err
+	}
+
+	stop = cancel
+	go run(ctx, conn)
+	return nil
+}
+
+// Stop ends the listener; the cache keeps its last contents
+func Stop() {
+	stop()
+}
+
+// run applies notifications to the cache, reconnecting on failure. Anything
+// announced while disconnected is picked up by reloading after reconnecting.
+func run(ctx context.Context, conn *pgx.Conn) {
+	backoff := time.Second
+
+	for {
+		if conn != nil {
+			err := relay(ctx, conn)
+			conn.Close(context.Background())
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("revocation: listener disconnected: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		var err error
+		conn, err = listen(ctx)
+		if err == nil {
+			err = reload()
+		}
+		if err != nil {
+			log.Printf("revocation: reconnect failed: %v", err)
+			if conn != nil {
+				conn.Close(context.Background())
+				conn = nil
+			}
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = time.Second
+	}
+}
+
+// relay blocks on notifications until the connection fails
+func relay(ctx context.Context, conn *pgx.Conn) error {
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal([]byte(notification.Payload), &msg); err != nil {
+			log.Printf("revocation: dropping malformed notification: %v", err)
+			continue
+		}
+
+		if msg.Before != nil {
+			applyUser(msg.UserID, *msg.Before)
+		} else if msg.JTI != "" {
+			applyToken(msg.JTI, msg.ExpiresAt)
+		}
+	}
+}
+
+// listen opens a dedicated connection subscribed to the notify channel
+func listen(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := pgx.Connect(ctx, config.DSN())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+	return conn, nil
+}
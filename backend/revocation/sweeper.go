@@ -0,0 +1,58 @@
+package revocation
+
+import (
+	"log"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+)
+
+// sweepInterval is how often expired revocation and refresh-token rows are deleted
+const sweepInterval = time.Hour
+
+// StartSweeper periodically deletes rows that can no longer affect any token
+func StartSweeper() {
+	go func() {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+
+		for {
+			Sweep(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+// Sweep deletes expired blacklist entries, expired or logged-out refresh
+// tokens, unredeemed stream tickets and abandoned OIDC login states, and
+// drops stale cache entries
+func Sweep(now time.Time) {
+	result := config.DB.Where("expires_at <= ?", now).Delete(&models.BlacklistedToken{})
+	if result.Error != nil {
+		log.Printf("revocation: failed to sweep blacklisted tokens: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("revocation: swept %d expired blacklisted tokens", result.RowsAffected)
+	}
+
+	// Logout soft-deletes refresh tokens, so remove those rows too
+	result = config.DB.Unscoped().Where("expires_at <= ? OR deleted_at IS NOT NULL", now).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		log.Printf("revocation: failed to sweep refresh tokens: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("revocation: swept %d expired refresh tokens", result.RowsAffected)
+	}
+
+	result = config.DB.Where("expires_at <= ?", now).Delete(&models.StreamTicket{})
+	if result.Error != nil {
+		log.Printf("revocation: failed to sweep stream tickets: %v", result.Error)
+	}
+
+	// Abandoned social login attempts
+	result = config.DB.Where("expires_at <= ?", now).Delete(&models.OIDCLoginState{})
+	if result.Error != nil {
+		log.Printf("revocation: failed to sweep login states: %v", result.Error)
+	}
+
+	prune(now)
+}
@@ -16,13 +16,60 @@ type JWTClaim struct {
 	jwt.RegisteredClaims
 }
 
-// GenerateAccessToken creates a short-lived access token (15 minutes)
-func GenerateAccessToken(userID uint, email string, username string) (string, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return "", errors.New("JWT_SECRET not set in environment")
+// Audiences of internal token types, kept distinct from JWTAudience so they
+// can never be accepted as access tokens
+const (
+	refreshAudience = "refresh"
+	mfaAudience     = "mfa"
+)
+
+// signToken signs claims with the current key from the key ring
+func signToken(claims jwt.Claims) (string, error) {
+	key, err := currentSigningKey()
+	if err != nil {
+		return "", err
 	}
 
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.Private)
+}
+
+// parseToken verifies a token against the key ring named by its kid header
+// and checks the issuer and audience
+func parseToken(tokenString string, claims jwt.Claims, audience string) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := verificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// Verify signing method matches the key
+		if token.Method.Alg() != key.Algorithm {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.Private.Public(), nil
+	},
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+		jwt.WithIssuer(JWTIssuer()),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if !token.Valid {
+		return errors.New("invalid token")
+	}
+
+	return nil
+}
+
+// AccessTokenLifetime is how long access tokens stay valid, which bounds
+// how long a revocation has to be remembered
+func AccessTokenLifetime() (time.Duration, error) {
 	expiryMinutesStr := os.Getenv("ACCESS_TOKEN_EXPIRY_MINUTES")
 	if expiryMinutesStr == "" {
 		expiryMinutesStr = "15" // Default to 15 minutes
@@ -30,38 +77,48 @@ func GenerateAccessToken(userID uint, email string, username string) (string, er
 
 	expiryMinutes, err := strconv.Atoi(expiryMinutesStr)
 	if err != nil {
-		return "", errors.New("invalid ACCESS_TOKEN_EXPIRY_MINUTES value")
+		return 0, errors.New("invalid ACCESS_TOKEN_EXPIRY_MINUTES value")
 	}
 
-	expirationTime := time.Now().Add(time.Duration(expiryMinutes) * time.Minute)
+	return time.Duration(expiryMinutes) * time.Minute, nil
+}
+
+// GenerateAccessToken creates a short-lived access token (15 minutes)
+func GenerateAccessToken(userID uint, email string, username string) (string, error) {
+	lifetime, err := AccessTokenLifetime()
+	if err != nil {
+		return "", err
+	}
+
+	// Unique token ID so a single token can be revoked
+	jti, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	expirationTime := time.Now().Add(lifetime)
 
 	claims := &JWTClaim{
 		UserID:   userID,
 		Email:    email,
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    JWTIssuer(),
+			Subject:   strconv.Itoa(int(userID)),
+			Audience:  jwt.ClaimStrings{JWTAudience()},
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return "", err
-	}
-
-	return tokenString, nil
+	return signToken(claims)
 }
 
-// GenerateRefreshToken creates a long-lived refresh token (7 days)
-func GenerateRefreshToken(userID uint) (tokenString string, expiresAt time.Time, err error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return "", time.Time{}, errors.New("JWT_SECRET not set in environment")
-	}
-
+// RefreshTokenLifetime is how long refresh tokens stay valid, which bounds
+// how long a retired signing key must remain verifiable
+func RefreshTokenLifetime() (time.Duration, error) {
 	expiryDaysStr := os.Getenv("REFRESH_TOKEN_EXPIRY_DAYS")
 	if expiryDaysStr == "" {
 		expiryDaysStr = "7" // Default to 7 days
@@ -69,20 +126,31 @@ func GenerateRefreshToken(userID uint) (tokenString string, expiresAt time.Time,
 
 	expiryDays, err := strconv.Atoi(expiryDaysStr)
 	if err != nil {
-		return "", time.Time{}, errors.New("invalid REFRESH_TOKEN_EXPIRY_DAYS value")
+		return 0, errors.New("invalid REFRESH_TOKEN_EXPIRY_DAYS value")
+	}
+
+	return time.Duration(expiryDays) * 24 * time.Hour, nil
+}
+
+// GenerateRefreshToken creates a long-lived refresh token (7 days)
+func GenerateRefreshToken(userID uint) (tokenString string, expiresAt time.Time, err error) {
+	lifetime, err := RefreshTokenLifetime()
+	if err != nil {
+		return "", time.Time{}, err
 	}
 
-	expirationTime := time.Now().Add(time.Duration(expiryDays) * 24 * time.Hour)
+	expirationTime := time.Now().Add(lifetime)
 
 	claims := &jwt.RegisteredClaims{
+		Issuer:    JWTIssuer(),
 		Subject:   strconv.Itoa(int(userID)),
+		Audience:  jwt.ClaimStrings{refreshAudience},
 		ExpiresAt: jwt.NewNumericDate(expirationTime),
 		IssuedAt:  jwt.NewNumericDate(time.Now()),
 		NotBefore: jwt.NewNumericDate(time.Now()),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err = token.SignedString([]byte(jwtSecret))
+	tokenString, err = signToken(claims)
 	if err != nil {
 		return "", time.Time{}, err
 	}
@@ -92,27 +160,13 @@ func GenerateRefreshToken(userID uint) (tokenString string, expiresAt time.Time,
 
 // ValidateJWT parses and validates an access token
 func ValidateJWT(tokenString string) (*JWTClaim, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return nil, errors.New("JWT_SECRET not set in environment")
-	}
-
 	claims := &JWTClaim{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(jwtSecret), nil
-	})
-
-	if err != nil {
+	if err := parseToken(tokenString, claims, JWTAudience()); err != nil {
 		return nil, err
 	}
 
-	if !token.Valid {
-		return nil, errors.New("invalid token")
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return nil, errors.New("token is missing jti or iat")
 	}
 
 	return claims, nil
@@ -120,30 +174,45 @@ func ValidateJWT(tokenString string) (*JWTClaim, error) {
 
 // ValidateRefreshToken parses and validates a refresh token, returns userID
 func ValidateRefreshToken(tokenString string) (uint, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return 0, errors.New("JWT_SECRET not set in environment")
+	claims := &jwt.RegisteredClaims{}
+	if err := parseToken(tokenString, claims, refreshAudience); err != nil {
+		return 0, err
 	}
 
-	claims := &jwt.RegisteredClaims{}
+	// Extract user ID from Subject claim
+	userID, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil {
+		return 0, errors.New("invalid user ID in token")
+	}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(jwtSecret), nil
-	})
+	return uint(userID), nil
+}
 
-	if err != nil {
-		return 0, err
+// mfaTokenExpiry is how long a user has to enter their second factor
+const mfaTokenExpiry = 5 * time.Minute
+
+// GenerateMFAToken creates a short-lived challenge token (5 minutes) issued
+// after the password step when two-factor authentication is enabled
+func GenerateMFAToken(userID uint) (string, error) {
+	claims := &jwt.RegisteredClaims{
+		Issuer:    JWTIssuer(),
+		Subject:   strconv.Itoa(int(userID)),
+		Audience:  jwt.ClaimStrings{mfaAudience},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaTokenExpiry)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
 	}
 
-	if !token.Valid {
-		return 0, errors.New("invalid token")
+	return signToken(claims)
+}
+
+// ValidateMFAToken parses a challenge token and returns its user ID
+func ValidateMFAToken(tokenString string) (uint, error) {
+	claims := &jwt.RegisteredClaims{}
+	if err := parseToken(tokenString, claims, mfaAudience); err != nil {
+		return 0, err
 	}
 
-	// Extract user ID from Subject claim
 	userID, err := strconv.ParseUint(claims.Subject, 10, 32)
 	if err != nil {
 		return 0, errors.New("invalid user ID in token")
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Supported JWT signing algorithms
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// SigningKey is a decoded key from the key ring
+type SigningKey struct {
+	KID         string
+	Algorithm   string
+	Private     crypto.Signer
+	ActivatesAt time.Time
+}
+
+// JWK is the public half of a signing key in JSON Web Key form
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+var (
+	keyRingMu sync.RWMutex
+	keyRing   []*SigningKey // Sorted by ActivatesAt
+)
+
+// SetKeyRing replaces the keys used to sign and verify tokens
+func SetKeyRing(keys []*SigningKey) {
+	sorted := append([]*SigningKey(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ActivatesAt.Before(sorted[j].ActivatesAt) })
+
+	keyRingMu.Lock()
+	keyRing = sorted
+	keyRingMu.Unlock()
+}
+
+// currentSigningKey returns the most recently activated key
+func currentSigningKey() (*SigningKey, error) {
+	now := time.Now()
+
+	keyRingMu.RLock()
+	defer keyRingMu.RUnlock()
+
+	for i := len(keyRing) - 1; i >= 0; i-- {
+		if !keyRing[i].ActivatesAt.After(now) {
+			return keyRing[i], nil
+		}
+	}
+	return nil, errors.New("no active signing key")
+}
+
+// verificationKey finds a published key by its kid
+func verificationKey(kid string) (*SigningKey, error) {
+	keyRingMu.RLock()
+	defer keyRingMu.RUnlock()
+
+	for _, key := range keyRing {
+		if key.KID == kid {
+			return key, nil
+		}
+	}
+	return nil, errors.New("unknown signing key")
+}
+
+// JWKS returns the public keys of every published key, including keys that
+// are not yet active so verifiers can cache them before first use
+func JWKS() []JWK {
+	keyRingMu.RLock()
+	defer keyRingMu.RUnlock()
+
+	jwks := make([]JWK, 0, len(keyRing))
+	for _, key := range keyRing {
+		jwk := JWK{Use: "sig", Alg: key.Algorithm, Kid: key.KID}
+		switch pub := key.Private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
+
+// JWTIssuer is the iss claim set on and required of our tokens
+func JWTIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "blog-api"
+}
+
+// JWTAudience is the aud claim of access tokens
+func JWTAudience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return "blog-api"
+}
@@ -0,0 +1,231 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const (
+	pollInterval  = 15 * time.Second
+	batchSize     = 20
+	leaseDuration = 2 * time.Minute // How long a claimed delivery is hidden from other workers
+	baseBackoff   = 30 * time.Second
+	maxBackoff    = 6 * time.Hour
+)
+
+var (
+	wake   = make(chan struct{}, 1)
+	client = newClient()
+)
+
+// Wake asks the worker to process pending deliveries now
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// StartWorker delivers pending webhooks in the background
+func StartWorker() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			processDue()
+
+			select {
+			case <-ticker.C:
+			case <-wake:
+			}
+		}
+	}()
+}
+
+// processDue claims due deliveries and attempts each one
+func processDue() {
+	for {
+		deliveries, err := claimDue()
+		if err != nil {
+			log.Printf("webhooks: failed to claim deliveries: %v", err)
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		for i := range deliveries {
+			attempt(&deliveries[i])
+		}
+	}
+}
+
+// claimDue locks a batch of due deliveries and pushes their next attempt past
+// the lease, so other replicas skip them while this one is sending.
+func claimDue() ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+			Order("next_attempt_at ASC").
+			Limit(batchSize).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(leaseDuration)).Error
+	})
+
+	return deliveries, err
+}
+
+// attempt sends one delivery and records the outcome
+func attempt(delivery *models.WebhookDelivery) {
+	var hook models.Webhook
+	if err := config.DB.First(&hook, delivery.WebhookID).Error; err != nil || !hook.Active {
+		delivery.Status = StatusFailed
+		delivery.Error = "webhook deleted or disabled"
+		delivery.NextAttemptAt = nil
+		config.DB.Save(delivery)
+		return
+	}
+
+	delivery.Attempts++
+	code, err := send(hook, delivery)
+	delivery.ResponseCode = code
+
+	now := time.Now()
+	switch {
+	case err == nil && code >= 200 && code < 300:
+		delivery.Status = StatusSucceeded
+		delivery.Error = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= maxAttempts():
+		delivery.Status = StatusFailed
+		delivery.Error = failureReason(code, err)
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(Backoff(delivery.Attempts))
+		delivery.Error = failureReason(code, err)
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := config.DB.Save(delivery).Error; err != nil {
+		log.Printf("webhooks: failed to record delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// send posts the signed payload to the webhook URL. Only the status code is
+// kept; response bodies are discarded so they can't be read back.
+func send(hook models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Blog-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(hook.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// Sign computes the hex HMAC-SHA256 of "timestamp.body" with the webhook secret.
+// Receivers recompute it to verify the sender and reject replays by timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the wait before the next attempt: 30s, 1m, 2m, ... capped at 6h
+func Backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// maxAttempts reads WEBHOOK_MAX_ATTEMPTS (default 8)
+func maxAttempts() int {
+	if n, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && n > 0 {
+		return n
+	}
+	return 8
+}
+
+func failureReason(code int, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "unexpected status " + strconv.Itoa(code)
+}
+
+// Redeliver queues a fresh copy of a previous delivery
+func Redeliver(original models.WebhookDelivery) (models.WebhookDelivery, error) {
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        StatusPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  &original.ID,
+	}
+
+	if err := config.DB.Create(&delivery).Error; err != nil {
+		return delivery, err
+	}
+
+	Wake()
+	return delivery, nil
+}
@@ -0,0 +1,106 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"gin-quickstart/config"
+	"gin-quickstart/models"
+)
+
+// Supported webhook events
+const (
+	EventPostPublished   = "post.published"
+	EventPostUpdated     = "post.updated"
+	EventPostUnpublished = "post.unpublished"
+	EventPostDeleted     = "post.deleted"
+	EventCommentCreated  = "comment.created"
+	EventUserFollowed    = "user.followed"
+)
+
+// Events lists every event a webhook may subscribe to
+var Events = []string{
+	EventPostPublished,
+	EventPostUpdated,
+	EventPostUnpublished,
+	EventPostDeleted,
+	EventCommentCreated,
+	EventUserFollowed,
+}
+
+// IsValidEvent reports whether name is a supported event
+func IsValidEvent(name string) bool {
+	for _, e := range Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload is the JSON body posted to webhook endpoints
+type Payload struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Emit queues a delivery of the event to every active webhook subscribed to it.
+// ownerID is the user the event belongs to (post author, followed user); their
+// own webhooks receive it, as do admin-registered global webhooks.
+func Emit(event string, ownerID uint, data interface{}) {
+	var hooks []models.Webhook
+	if err := config.DB.Where("active = ? AND (user_id = ? OR global = ?)", true, ownerID, true).
+		Find(&hooks).Error; err != nil {
+		log.Printf("webhooks: failed to load webhooks for %s: %v", event, err)
+		return
+	}
+
+	var body []byte
+	now := time.Now()
+	queued := false
+
+	for _, hook := range hooks {
+		if !subscribed(hook, event) {
+			continue
+		}
+
+		if body == nil {
+			var err error
+			body, err = json.Marshal(Payload{Event: event, CreatedAt: now, Data: data})
+			if err != nil {
+				log.Printf("webhooks: failed to encode %s payload: %v", event, err)
+				return
+			}
+		}
+
+		delivery := models.WebhookDelivery{
+			WebhookID:     hook.ID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        StatusPending,
+			NextAttemptAt: &now,
+		}
+		if err := config.DB.Create(&delivery).Error; err != nil {
+			log.Printf("webhooks: failed to queue %s for webhook %d: %v", event, hook.ID, err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		Wake()
+	}
+}
+
+// subscribed reports whether the webhook listens for the event
+func subscribed(hook models.Webhook, event string) bool {
+	for _, e := range strings.Split(hook.Events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
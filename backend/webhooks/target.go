@@ -0,0 +1,93 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var errPrivateTarget = errors.New("webhook target is not a public address")
+
+// ValidateURL checks that a webhook endpoint is an https URL whose host
+// resolves only to public addresses. Deliveries check the address again
+// when they connect, since DNS can change after registration.
+func ValidateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return errors.New("URL must be an absolute https URL")
+	}
+	if parsed.User != nil {
+		return errors.New("URL must not contain credentials")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return errors.New("URL host could not be resolved")
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return errors.New("URL must point to a public host")
+		}
+	}
+	return nil
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address,
+// rejecting loopback, private, link-local (including cloud metadata
+// endpoints), multicast and unspecified addresses
+func isPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		// Carrier-grade NAT, 100.64.0.0/10
+		if ip[0] == 100 && ip[1]&0xc0 == 64 {
+			return false
+		}
+		// "This network", 0.0.0.0/8
+		if ip[0] == 0 {
+			return false
+		}
+	}
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast()
+}
+
+// dialControl refuses connections to non-public addresses. It runs after
+// DNS resolution, so a host that rebinds to an internal address after
+// registration is still blocked.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return errPrivateTarget
+	}
+	return nil
+}
+
+// newClient returns the HTTP client used for deliveries. It ignores proxy
+// settings so every connection goes through dialControl, and does not follow
+// redirects.
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: dialControl}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+	}
+}